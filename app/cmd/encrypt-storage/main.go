@@ -0,0 +1,47 @@
+// Command encrypt-storage encrypts every plaintext object in the configured
+// storage backend in place, using STORAGE_ENCRYPTION_KEY. Objects that are
+// already encrypted are skipped, so it is safe to run more than once.
+package main
+
+import (
+	"context"
+	"log"
+
+	"FP-DevOps/config"
+	"FP-DevOps/storage"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+func main() {
+	ctx := context.Background()
+	backend := config.SetUpStorageBackend()
+
+	encrypter, ok := backend.(storage.Encrypter)
+	if !ok {
+		log.Fatal("STORAGE_ENCRYPTION_KEY is not set")
+	}
+
+	objects, err := backend.List(ctx, "")
+	if err != nil {
+		log.Fatalf("error listing objects: %v", err)
+	}
+
+	var encrypted, failed int
+	for _, object := range objects {
+		ok, err := encrypter.EncryptObject(ctx, object.Key)
+		if err != nil {
+			log.Printf("error encrypting %s: %v", object.Key, err)
+			failed++
+			continue
+		}
+		if ok {
+			encrypted++
+		}
+	}
+
+	log.Printf("encrypted %d of %d objects, %d failed", encrypted, len(objects), failed)
+	if failed > 0 {
+		log.Fatal("some objects could not be encrypted")
+	}
+}
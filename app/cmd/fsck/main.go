@@ -0,0 +1,51 @@
+// Command fsck checks the configured storage backend against the database. It
+// reports objects nothing references, referenced objects that are missing and
+// blob reference counts that drifted. Unless run with -mode=dry-run, orphans
+// are quarantined under quarantine/ or deleted and reference counts fixed.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"FP-DevOps/config"
+	"FP-DevOps/constants"
+	"FP-DevOps/repository"
+	"FP-DevOps/service"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+func main() {
+	mode := flag.String("mode", constants.ENUM_FSCK_MODE_DRY_RUN, "dry-run, quarantine or delete")
+	grace := flag.Duration("grace", -1, "leave alone content changed more recently (default FSCK_GRACE_PERIOD_MINUTES)")
+	flag.Parse()
+
+	db := config.SetUpDatabaseConnection()
+	fsckService := service.NewFsckService(repository.NewFsckRepository(db, config.SetUpStorageBackend()))
+
+	opts := fsckService.Options()
+	opts.Mode = *mode
+	if *grace >= 0 {
+		opts.GracePeriod = *grace
+	}
+
+	report, err := fsckService.Run(context.Background(), opts)
+	if err != nil {
+		log.Fatalf("error checking storage: %v", err)
+	}
+
+	for _, key := range report.OrphanObjects {
+		log.Printf("orphan object: %s", key)
+	}
+	for _, key := range report.MissingObjects {
+		log.Printf("missing object: %s", key)
+	}
+	for _, mismatch := range report.RefCountMismatches {
+		log.Printf("blob %s has reference count %d, used %d times", mismatch.Checksum, mismatch.RefCount, mismatch.Actual)
+	}
+
+	log.Printf("checked %d objects: %d orphans, %d missing, %d reference count mismatches", report.Objects, len(report.OrphanObjects), len(report.MissingObjects), len(report.RefCountMismatches))
+	log.Printf("quarantined %d, deleted %d, fixed %d", report.Quarantined, report.Deleted, report.Fixed)
+}
@@ -43,7 +43,17 @@ func SetUpDatabaseConnection() *gorm.DB {
 
 	if err := db.AutoMigrate(
 		&entity.User{},
+		&entity.Team{},
+		&entity.TeamMember{},
+		&entity.TeamInvitation{},
+		&entity.Folder{},
 		&entity.File{},
+		&entity.Tag{},
+		&entity.ShareLink{},
+		&entity.FileGrant{},
+		&entity.FileVersion{},
+		&entity.Blob{},
+		&entity.Upload{},
 	); err != nil {
 		panic(err)
 	}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"FP-DevOps/constants"
+	"FP-DevOps/storage"
+)
+
+// SetUpStorageBackend returns the backend selected by STORAGE_DRIVER, which
+// encrypts content at rest when STORAGE_ENCRYPTION_KEY is set.
+func SetUpStorageBackend() storage.Backend {
+	backend := setUpStorageDriver()
+
+	masterKey := os.Getenv("STORAGE_ENCRYPTION_KEY")
+	if masterKey == "" {
+		return backend
+	}
+
+	key, err := hex.DecodeString(masterKey)
+	if err != nil {
+		panic(fmt.Sprintf("invalid STORAGE_ENCRYPTION_KEY: %v", err))
+	}
+
+	encrypted, err := storage.NewEncryptedBackend(backend, key)
+	if err != nil {
+		panic(err)
+	}
+	return encrypted
+}
+
+func setUpStorageDriver() storage.Backend {
+	driver := os.Getenv("STORAGE_DRIVER")
+	if driver == "" {
+		driver = constants.ENUM_STORAGE_LOCAL
+	}
+
+	switch driver {
+	case constants.ENUM_STORAGE_LOCAL:
+		root := os.Getenv("STORAGE_LOCAL_ROOT")
+		if root == "" {
+			root = constants.FILE_STORAGE_DIRECTORY
+		}
+		return storage.NewLocalBackend(root)
+	case constants.ENUM_STORAGE_S3:
+		backend, err := storage.NewS3Backend(storage.S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			Prefix:          os.Getenv("S3_PREFIX"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			UsePathStyle:    os.Getenv("S3_FORCE_PATH_STYLE") == "true",
+		})
+		if err != nil {
+			panic(err)
+		}
+		return backend
+	case constants.ENUM_STORAGE_AZURE:
+		backend, err := storage.NewAzureBlobBackend(storage.AzureBlobConfig{
+			Endpoint:        os.Getenv("AZURE_STORAGE_ENDPOINT"),
+			Account:         os.Getenv("AZURE_STORAGE_ACCOUNT"),
+			Container:       os.Getenv("AZURE_STORAGE_CONTAINER"),
+			Prefix:          os.Getenv("AZURE_STORAGE_PREFIX"),
+			AccountKey:      os.Getenv("AZURE_STORAGE_KEY"),
+			SASToken:        os.Getenv("AZURE_STORAGE_SAS_TOKEN"),
+			CreateContainer: os.Getenv("AZURE_STORAGE_CREATE_CONTAINER") == "true",
+		})
+		if err != nil {
+			panic(err)
+		}
+		return backend
+	default:
+		panic(fmt.Sprintf("unknown storage driver %q", driver))
+	}
+}
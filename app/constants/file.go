@@ -3,4 +3,105 @@ package constants
 const (
 	FILE_STORAGE_DIRECTORY = "storage"
 	MB                     = 1 << 20
+
+	DEFAULT_MAX_UPLOAD_SIZE_MB = 20
+	DEFAULT_STORAGE_QUOTA_MB   = 1024
+
+	// files uploaded in one request are stored this many at a time
+	UPLOAD_CONCURRENCY   = 4
+	MAX_FILES_PER_UPLOAD = 100
+
+	DEFAULT_FILE_VERSION_RETENTION = 10
+
+	DEFAULT_TRASH_RETENTION_DAYS    = 30
+	TRASH_PURGE_INTERVAL_IN_MINUTES = 60
+
+	// ROOT_FOLDER_ID selects the files and folders outside of any folder
+	ROOT_FOLDER_ID = "root"
+
+	BLOB_STORAGE_PREFIX              = "blobs"
+	UPLOAD_STORAGE_PREFIX            = "uploads"
+	TUS_VERSION                      = "1.0.0"
+	TUS_EXTENSIONS                   = "creation,expiration"
+	DEFAULT_UPLOAD_EXPIRATION_HOURS  = 24
+	UPLOAD_PURGE_INTERVAL_IN_MINUTES = 60
+
+	THUMBNAIL_STORAGE_PREFIX = "thumbnails"
+	THUMBNAIL_SIZE_SMALL     = 128
+	THUMBNAIL_SIZE_MEDIUM    = 256
+	THUMBNAIL_SIZE_LARGE     = 512
+	THUMBNAIL_WORKERS        = 2
+	THUMBNAIL_QUEUE_SIZE     = 256
+	// images with more pixels than this are not decoded for thumbnails
+	MAX_THUMBNAIL_SOURCE_PIXELS = 50_000_000
+	// images whose thumbnails failed are not tried again for a while, at most
+	// this many of them are remembered
+	THUMBNAIL_FAILURE_TTL_IN_MINUTES = 60
+	MAX_THUMBNAIL_FAILURES           = 10_000
+
+	// only the start of larger files is rendered in previews
+	MAX_PREVIEW_SIZE      = 1 * MB
+	PREVIEW_ROWS_PER_PAGE = 50
+	ENUM_PREVIEW_MARKDOWN = "markdown"
+	ENUM_PREVIEW_CODE     = "code"
+	ENUM_PREVIEW_TABLE    = "table"
+
+	// inline previews on the share pages of files, text files are only shown
+	// up to MAX_SHARE_PAGE_TEXT_SIZE
+	ENUM_SHARE_PREVIEW_IMAGE = "image"
+	ENUM_SHARE_PREVIEW_PDF   = "pdf"
+	ENUM_SHARE_PREVIEW_TEXT  = "text"
+	MAX_SHARE_PAGE_TEXT_SIZE = 64 << 10
+
+	// files are listed newest first unless another sort is asked for
+	DEFAULT_FILE_SORT  = "created_at"
+	DEFAULT_FILE_ORDER = "desc"
+
+	// permissions of users a file is shared with, each includes the ones
+	// before it. Owners can do anything with their files.
+	ENUM_PERMISSION_VIEW   = "view"
+	ENUM_PERMISSION_EDIT   = "edit"
+	ENUM_PERMISSION_MANAGE = "manage"
+
+	// roles of team members, owners and admins manage the team's files,
+	// members edit them and viewers can only read them
+	ENUM_TEAM_ROLE_OWNER  = "owner"
+	ENUM_TEAM_ROLE_ADMIN  = "admin"
+	ENUM_TEAM_ROLE_MEMBER = "member"
+	ENUM_TEAM_ROLE_VIEWER = "viewer"
+
+	// share link tokens are this many random bytes, encoded in base64
+	SHARE_LINK_TOKEN_BYTES = 32
+	SHARE_LINK_PATH        = "/s/"
+
+	// signed download URLs are valid for an hour unless asked otherwise, and
+	// for a week at most
+	DEFAULT_SIGNED_URL_TTL_SECONDS = 60 * 60
+	MAX_SIGNED_URL_TTL_SECONDS     = 7 * 24 * 60 * 60
+
+	// tag names are stored lowercase, made of letters, digits, spaces, dots,
+	// dashes and underscores
+	MAX_TAG_LENGTH    = 50
+	MAX_TAGS_PER_FILE = 20
+
+	MAX_ARCHIVE_FILES        = 1000
+	DEFAULT_ARCHIVE_FILENAME = "files.zip"
+
+	// limits on uploaded archives that are extracted, the size is the total
+	// of the extracted files which may also not exceed the archive's size
+	// times the ratio
+	MAX_EXTRACT_ENTRIES = 1000
+	MAX_EXTRACT_SIZE_MB = 512
+	MAX_EXTRACT_RATIO   = 100
+
+	QUARANTINE_STORAGE_PREFIX            = "quarantine"
+	DEFAULT_FSCK_GRACE_PERIOD_IN_MINUTES = 60
+
+	ENUM_FSCK_MODE_DRY_RUN    = "dry-run"
+	ENUM_FSCK_MODE_QUARANTINE = "quarantine"
+	ENUM_FSCK_MODE_DELETE     = "delete"
+
+	ENUM_STORAGE_LOCAL = "local"
+	ENUM_STORAGE_S3    = "s3"
+	ENUM_STORAGE_AZURE = "azure"
 )
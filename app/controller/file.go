@@ -6,7 +6,14 @@ import (
 	"FP-DevOps/dto"
 	"FP-DevOps/service"
 	"FP-DevOps/utils"
+	"html/template"
+	"io"
+	"log"
+	"mime/multipart"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,7 +24,23 @@ type (
 		UpdateByID(ctx *gin.Context)
 		DeleteByID(ctx *gin.Context)
 		GetFileByID(ctx *gin.Context)
+		SignURL(ctx *gin.Context)
+		GetThumbnailByID(ctx *gin.Context)
+		GetPreviewByID(ctx *gin.Context)
+		GetSharePage(ctx *gin.Context)
+		CreateArchive(ctx *gin.Context)
+		Extract(ctx *gin.Context)
 		GetPaginated(ctx *gin.Context)
+		GetTeamFiles(ctx *gin.Context)
+		CreateTeamFiles(ctx *gin.Context)
+		MoveByID(ctx *gin.Context)
+		CreateVersion(ctx *gin.Context)
+		GetVersions(ctx *gin.Context)
+		GetVersion(ctx *gin.Context)
+		RestoreVersion(ctx *gin.Context)
+		GetTrash(ctx *gin.Context)
+		RestoreByID(ctx *gin.Context)
+		DeletePermanentlyByID(ctx *gin.Context)
 	}
 
 	fileController struct {
@@ -33,22 +56,192 @@ func NewFileController(fs service.FileService, jwt config.JWTService) FileContro
 	}
 }
 
+// filePartReader reads the multipart body one file part at a time, so
+// uploaded files are streamed to storage instead of being buffered by
+// ShouldBind. The returned function yields the next file part with the folder
+// it goes to, and io.EOF after the last one. The folder can be given as a
+// query parameter or as a form field that comes before the files.
+func filePartReader(ctx *gin.Context) (func() (*multipart.Part, string, error), error) {
+	reader, err := ctx.Request.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	folderID := ctx.Query("folder_id")
+	return func() (*multipart.Part, string, error) {
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				return nil, "", err
+			}
+
+			if part.FormName() == "file" && part.FileName() != "" {
+				return part, folderID, nil
+			}
+			if part.FormName() == "folder_id" {
+				value, err := io.ReadAll(io.LimitReader(part, 64))
+				if err != nil {
+					return nil, "", err
+				}
+				folderID = string(value)
+			}
+			part.Close()
+		}
+	}, nil
+}
+
+// readFilePart reads the multipart body up to its first file part.
+func readFilePart(ctx *gin.Context) (*multipart.Part, string, error) {
+	next, err := filePartReader(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	part, folderID, err := next()
+	if err == io.EOF {
+		err = dto.ErrFileRequired
+	}
+	return part, folderID, err
+}
+
+// serveFile sends the content of a file, inline when view is set.
+func serveFile(ctx *gin.Context, res dto.FileResponse, view bool) {
+	if view {
+		ctx.Header("Content-Disposition", "inline; filename="+res.Filename)
+		ctx.Header("Content-Type", res.MimeType)
+	} else {
+		ctx.Header("Content-Disposition", "attachment; filename="+res.Filename)
+		ctx.Header("Content-Type", "application/octet-stream")
+	}
+
+	// ServeContent takes care of HEAD, Range/If-Range and conditional requests,
+	// only reading the ranges that are actually sent from storage
+	if res.Checksum != "" {
+		ctx.Header("ETag", `"`+res.Checksum+`"`)
+	}
+	http.ServeContent(ctx.Writer, ctx.Request, res.Filename, res.ModTime, res.Content)
+}
+
+func createFileStatus(err error) int {
+	if err == dto.ErrFileSizeExceeded {
+		return http.StatusRequestEntityTooLarge
+	} else if err == dto.ErrStorageQuotaExceeded {
+		return http.StatusInsufficientStorage
+	} else if err == dto.ErrFolderNotFound || err == dto.ErrTeamNotFound {
+		return http.StatusNotFound
+	} else if err == dto.ErrUnauthorizedFolderAccess || err == dto.ErrUnauthorizedTeamAccess {
+		return http.StatusForbidden
+	} else if err == dto.ErrTeamMismatch {
+		return http.StatusConflict
+	} else if err == dto.ErrTooManyFiles {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
 func (c *fileController) Create(ctx *gin.Context) {
-	var req dto.CreateFileRequest
-	if err := ctx.ShouldBind(&req); err != nil {
+	c.create(ctx, "")
+}
+
+// CreateTeamFiles uploads files to the team of the path, at its root unless
+// a folder of the team is given.
+func (c *fileController) CreateTeamFiles(ctx *gin.Context) {
+	c.create(ctx, ctx.Param("id"))
+}
+
+// create uploads every file part of the request. A single file is answered
+// like before, several files get a 207 Multi-Status with a result per file.
+func (c *fileController) create(ctx *gin.Context, teamID string) {
+	next, err := filePartReader(ctx)
+	if err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	results, err := c.fileService.CreateBatch(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), func() (dto.CreateFileRequest, error) {
+		part, folderID, err := next()
+		if err != nil {
+			return dto.CreateFileRequest{}, err
+		}
+		return dto.CreateFileRequest{
+			Filename: part.FileName(),
+			FolderID: folderID,
+			TeamID:   teamID,
+			Content:  part,
+		}, nil
+	})
+	for i := range results {
+		results[i].Status = http.StatusCreated
+		if results[i].Err != nil {
+			results[i].Status = createFileStatus(results[i].Err)
+			results[i].Error = results[i].Err.Error()
+		}
+	}
+
+	if err != nil || len(results) == 0 {
+		if err == nil {
+			err = dto.ErrFileRequired
+		}
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), results)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if len(results) > 1 {
+		response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_FILES, results)
+		ctx.JSON(http.StatusMultiStatus, response)
+		return
+	}
+
+	if results[0].Err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_FILE, results[0].Error, nil)
+		ctx.AbortWithStatusJSON(results[0].Status, response)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_FILE, results[0].File)
+	ctx.JSON(http.StatusCreated, response)
+}
+
+func (c *fileController) Extract(ctx *gin.Context) {
+	part, folderID, err := readFilePart(ctx)
+	if err != nil {
 		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
 		return
 	}
+	defer part.Close()
 
-	res, err := c.fileService.Create(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), req)
+	req := dto.ExtractArchiveRequest{
+		Filename: part.FileName(),
+		FolderID: folderID,
+		Content:  part,
+	}
+
+	res, err := c.fileService.Extract(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), req)
 	if err != nil {
-		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_FILE, err.Error(), nil)
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_EXTRACT_ARCHIVE, err.Error(), nil)
+		switch err {
+		case dto.ErrUnsupportedArchive:
+			ctx.AbortWithStatusJSON(http.StatusUnsupportedMediaType, response)
+		case dto.ErrInvalidArchive, dto.ErrUnsafeArchivePath:
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		case dto.ErrFileSizeExceeded, dto.ErrArchiveExtractLimit:
+			ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, response)
+		case dto.ErrStorageQuotaExceeded:
+			ctx.AbortWithStatusJSON(http.StatusInsufficientStorage, response)
+		case dto.ErrFolderNotFound:
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response)
+		case dto.ErrUnauthorizedFolderAccess:
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		}
 		return
 	}
 
-	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_FILE, res)
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_EXTRACT_ARCHIVE, res)
 	ctx.JSON(http.StatusCreated, response)
 }
 
@@ -100,12 +293,20 @@ func (c *fileController) DeleteByID(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, response)
 }
 
+// GetFileByID sends a file to users who may view it, or to anyone holding a
+// signed URL of the file that has not expired.
 func (c *fileController) GetFileByID(ctx *gin.Context) {
 	id := ctx.Param("id")
 	view := ctx.Query("view")
 	userID := ctx.GetString(constants.CTX_KEY_USER_ID)
 
-	res, err := c.fileService.GetFile(ctx.Request.Context(), userID, id)
+	var res dto.FileResponse
+	var err error
+	if signature := ctx.Query("sig"); signature != "" {
+		res, err = c.fileService.GetSignedFile(ctx.Request.Context(), id, ctx.Query("expires"), signature)
+	} else {
+		res, err = c.fileService.GetFile(ctx.Request.Context(), userID, id)
+	}
 	if err != nil {
 		if err == dto.ErrUnauthorizedFileAccess {
 			ctx.HTML(http.StatusBadRequest, "privateError.tmpl", gin.H{
@@ -115,33 +316,256 @@ func (c *fileController) GetFileByID(ctx *gin.Context) {
 			return
 		}
 		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_FILE, err.Error(), nil)
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		if err == dto.ErrFileNotFound {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response)
+		} else if err == dto.ErrInvalidURLSignature {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+		} else if err == dto.ErrSignedURLExpired {
+			ctx.AbortWithStatusJSON(http.StatusGone, response)
+		} else {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		}
 		return
 	}
+	defer res.Content.Close()
 
-	if view != "" {
-		ctx.Header("Content-Disposition", "inline; filename="+res.Filename)
-		ctx.Header("Content-Type", res.MimeType)
-	} else {
-		ctx.Header("Content-Disposition", "attachment; filename="+res.Filename)
-		ctx.Header("Content-Type", "application/octet-stream")
+	serveFile(ctx, res, view != "")
+}
+
+// SignURL returns a URL to download the file without logging in, valid for
+// expires_in seconds.
+func (c *fileController) SignURL(ctx *gin.Context) {
+	var req dto.SignFileURLRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	res, err := c.fileService.SignURL(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"), req)
+	if err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_SIGN_FILE_URL, err.Error(), nil)
+		switch err {
+		case dto.ErrFileNotFound:
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response)
+		case dto.ErrUnauthorizedFileAccess:
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+		case dto.ErrInvalidSignedURLExpiry:
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		}
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_SIGN_FILE_URL, res)
+	ctx.JSON(http.StatusCreated, response)
+}
+
+func (c *fileController) GetThumbnailByID(ctx *gin.Context) {
+	size := constants.THUMBNAIL_SIZE_MEDIUM
+	if value := ctx.Query("size"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_THUMBNAIL, dto.ErrInvalidThumbnailSize.Error(), nil)
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+			return
+		}
+		size = parsed
+	}
+
+	res, err := c.fileService.GetThumbnail(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"), size)
+	if err != nil {
+		if err == dto.ErrThumbnailPending {
+			ctx.Header("Retry-After", "1")
+			response := utils.BuildResponseSuccess(dto.MESSAGE_THUMBNAIL_PENDING, nil)
+			ctx.AbortWithStatusJSON(http.StatusAccepted, response)
+			return
+		}
+
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_THUMBNAIL, err.Error(), nil)
+		switch err {
+		case dto.ErrInvalidThumbnailSize:
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		case dto.ErrUnauthorizedFileAccess:
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+		case dto.ErrFileNotFound, dto.ErrThumbnailNotFound:
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response)
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		}
+		return
+	}
+	defer res.Content.Close()
+
+	serveFile(ctx, res, true)
+}
+
+func (c *fileController) GetPreviewByID(ctx *gin.Context) {
+	id := ctx.Param("id")
+	page, _ := strconv.Atoi(ctx.Query("page"))
+
+	res, err := c.fileService.GetPreview(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), id, page)
+	if err != nil {
+		switch err {
+		case dto.ErrUnauthorizedFileAccess:
+			ctx.HTML(http.StatusBadRequest, "privateError.tmpl", gin.H{
+				"title":   "Unauthorized Access",
+				"message": "You do not have permission to access this file.",
+			})
+		case dto.ErrPreviewUnsupported:
+			// the browser can still show the raw file
+			ctx.Redirect(http.StatusFound, "/api/file/"+id+"?view=1")
+		case dto.ErrFileNotFound:
+			ctx.AbortWithStatusJSON(http.StatusNotFound, utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_PREVIEW, err.Error(), nil))
+		case dto.ErrInvalidTable:
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_PREVIEW, err.Error(), nil))
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_PREVIEW, err.Error(), nil))
+		}
+		return
+	}
+
+	prevPage, nextPage := 0, 0
+	if res.Page > 1 {
+		prevPage = res.Page - 1
+	}
+	if res.Page < res.MaxPage {
+		nextPage = res.Page + 1
+	}
+
+	ctx.HTML(http.StatusOK, "preview.tmpl", gin.H{
+		"title":     res.File.Filename,
+		"file":      res.File,
+		"kind":      res.Kind,
+		"content":   template.HTML(res.HTML),
+		"header":    res.Header,
+		"rows":      res.Rows,
+		"page":      res.Page,
+		"max_page":  res.MaxPage,
+		"prev_page": prevPage,
+		"next_page": nextPage,
+		"truncated": res.Truncated,
+	})
+}
+
+// requestOrigin is the URL the app is reached at. Behind a proxy it has to be
+// set in PUBLIC_URL, forwarded headers are not trusted as any client can send
+// them. Otherwise it is the scheme and host the request was sent to.
+func requestOrigin(ctx *gin.Context) string {
+	if publicURL := os.Getenv("PUBLIC_URL"); publicURL != "" {
+		return strings.TrimSuffix(publicURL, "/")
+	}
+
+	scheme := "http"
+	if ctx.Request.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + ctx.Request.Host
+}
+
+// GetSharePage renders the page a shared file links to, with a preview, a
+// download button and Open Graph metadata for chat tools unfurling the link.
+func (c *fileController) GetSharePage(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	res, err := c.fileService.GetSharePage(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), id)
+	if err != nil {
+		switch err {
+		case dto.ErrUnauthorizedFileAccess:
+			ctx.HTML(http.StatusBadRequest, "privateError.tmpl", gin.H{
+				"title":   "Unauthorized Access",
+				"message": "You do not have permission to access this file.",
+			})
+		case dto.ErrFileNotFound:
+			ctx.AbortWithStatusJSON(http.StatusNotFound, utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_SHARE_PAGE, err.Error(), nil))
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_SHARE_PAGE, err.Error(), nil))
+		}
+		return
+	}
+
+	origin := requestOrigin(ctx)
+	ctx.HTML(http.StatusOK, "share.tmpl", gin.H{
+		"title":     res.File.Filename,
+		"file":      res.File,
+		"size":      utils.FormatSize(res.File.Size),
+		"owner":     res.Owner,
+		"preview":   res.Preview,
+		"text":      res.Text,
+		"truncated": res.Truncated,
+		"url":       origin + "/share/" + id,
+		"view_url":  origin + "/api/file/" + id + "?view=1",
+	})
+}
+
+func (c *fileController) CreateArchive(ctx *gin.Context) {
+	var req dto.CreateArchiveRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	userID := ctx.GetString(constants.CTX_KEY_USER_ID)
+	res, err := c.fileService.GetArchive(ctx.Request.Context(), userID, req)
+	if err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_ARCHIVE, err.Error(), nil)
+		switch err {
+		case dto.ErrArchiveEmpty, dto.ErrArchiveTooLarge:
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		case dto.ErrUnauthorizedFileAccess, dto.ErrUnauthorizedFolderAccess:
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+		case dto.ErrFileNotFound, dto.ErrFolderNotFound:
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response)
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		}
+		return
 	}
 
-	ctx.Writer.Write(res.Content)
+	ctx.Header("Content-Disposition", "attachment; filename="+res.Filename)
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Status(http.StatusOK)
+
+	// the status is sent already, a failure can only cut the archive short
+	if err := c.fileService.WriteArchive(ctx.Request.Context(), userID, res, ctx.Writer); err != nil {
+		log.Printf("error writing archive: %v", err)
+		ctx.Abort()
+	}
 }
 
 func (c *fileController) GetPaginated(ctx *gin.Context) {
+	c.getPaginated(ctx, "")
+}
+
+// GetTeamFiles lists the files of the team of the path, with the same
+// filters as the user's own files.
+func (c *fileController) GetTeamFiles(ctx *gin.Context) {
+	c.getPaginated(ctx, ctx.Param("id"))
+}
+
+func (c *fileController) getPaginated(ctx *gin.Context, teamID string) {
 	var req dto.PaginationQuery
 	if err := ctx.ShouldBind(&req); err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
 		return
 	}
+	req.TeamID = teamID
 
 	result, err := c.fileService.GetPaginated(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), req)
 	if err != nil {
 		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_FILE, err.Error(), nil)
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		if err == dto.ErrFolderNotFound || err == dto.ErrTeamNotFound {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response)
+		} else if err == dto.ErrUnauthorizedFolderAccess || err == dto.ErrUnauthorizedTeamAccess {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+		} else if err == dto.ErrInvalidTagName || err == dto.ErrInvalidSort || err == dto.ErrInvalidFilter || err == dto.ErrTeamMismatch {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		} else {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		}
 		return
 	}
 
@@ -153,3 +577,183 @@ func (c *fileController) GetPaginated(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, res)
 }
+
+func (c *fileController) MoveByID(ctx *gin.Context) {
+	var req dto.MoveFileRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	res, err := c.fileService.Move(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"), req)
+	if err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_MOVE_FILE, err.Error(), nil)
+		if err == dto.ErrUnauthorizedFileAccess || err == dto.ErrUnauthorizedFolderAccess {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+		} else if err == dto.ErrFileNotFound || err == dto.ErrFolderNotFound {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response)
+		} else if err == dto.ErrTeamMismatch {
+			ctx.AbortWithStatusJSON(http.StatusConflict, response)
+		} else {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		}
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_MOVE_FILE, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func abortFileVersion(ctx *gin.Context, message string, err error) {
+	response := utils.BuildResponseFailed(message, err.Error(), nil)
+	switch err {
+	case dto.ErrUnauthorizedFileAccess:
+		ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+	case dto.ErrFileNotFound, dto.ErrFileVersionNotFound:
+		ctx.AbortWithStatusJSON(http.StatusNotFound, response)
+	case dto.ErrFileSizeExceeded:
+		ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, response)
+	case dto.ErrStorageQuotaExceeded:
+		ctx.AbortWithStatusJSON(http.StatusInsufficientStorage, response)
+	default:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+	}
+}
+
+// versionParam parses the version path parameter, aborting the request when
+// it is not a version number.
+func versionParam(ctx *gin.Context, message string) (int, bool) {
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil || version <= 0 {
+		abortFileVersion(ctx, message, dto.ErrFileVersionNotFound)
+		return 0, false
+	}
+	return version, true
+}
+
+func (c *fileController) CreateVersion(ctx *gin.Context) {
+	part, _, err := readFilePart(ctx)
+	if err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+	defer part.Close()
+
+	req := dto.CreateFileRequest{
+		Filename: part.FileName(),
+		Content:  part,
+	}
+
+	res, err := c.fileService.CreateVersion(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"), req)
+	if err != nil {
+		abortFileVersion(ctx, dto.MESSAGE_FAILED_CREATE_FILE_VERSION, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_FILE_VERSION, res)
+	ctx.JSON(http.StatusCreated, response)
+}
+
+func (c *fileController) GetVersions(ctx *gin.Context) {
+	res, err := c.fileService.GetVersions(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"))
+	if err != nil {
+		abortFileVersion(ctx, dto.MESSAGE_FAILED_GET_FILE_VERSION, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_FILE_VERSION, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *fileController) GetVersion(ctx *gin.Context) {
+	version, ok := versionParam(ctx, dto.MESSAGE_FAILED_GET_FILE_VERSION)
+	if !ok {
+		return
+	}
+
+	res, err := c.fileService.GetVersion(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"), version)
+	if err != nil {
+		abortFileVersion(ctx, dto.MESSAGE_FAILED_GET_FILE_VERSION, err)
+		return
+	}
+	defer res.Content.Close()
+
+	serveFile(ctx, res, ctx.Query("view") != "")
+}
+
+func (c *fileController) RestoreVersion(ctx *gin.Context) {
+	version, ok := versionParam(ctx, dto.MESSAGE_FAILED_RESTORE_FILE_VERSION)
+	if !ok {
+		return
+	}
+
+	res, err := c.fileService.RestoreVersion(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"), version)
+	if err != nil {
+		abortFileVersion(ctx, dto.MESSAGE_FAILED_RESTORE_FILE_VERSION, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_RESTORE_FILE_VERSION, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *fileController) GetTrash(ctx *gin.Context) {
+	var req dto.PaginationQuery
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.fileService.GetTrash(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), req)
+	if err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_TRASH, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	res := utils.Response{
+		Status:  true,
+		Message: dto.MESSAGE_SUCCESS_GET_TRASH,
+		Data:    result.Data,
+		Meta:    result.PaginationMetadata,
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *fileController) RestoreByID(ctx *gin.Context) {
+	res, err := c.fileService.Restore(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"))
+	if err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_RESTORE_FILE, err.Error(), nil)
+		if err == dto.ErrUnauthorizedFileAccess {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+		} else if err == dto.ErrFileNotFound {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response)
+		} else {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		}
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_RESTORE_FILE, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *fileController) DeletePermanentlyByID(ctx *gin.Context) {
+	if err := c.fileService.DeletePermanently(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id")); err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DELETE_FILE, err.Error(), nil)
+		if err == dto.ErrUnauthorizedFileAccess {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+		} else if err == dto.ErrFileNotFound {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response)
+		} else {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		}
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_DELETE_FILE, nil)
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/service"
+	"FP-DevOps/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	FolderController interface {
+		Create(ctx *gin.Context)
+		GetFolder(ctx *gin.Context)
+		GetTeamRoot(ctx *gin.Context)
+		RenameByID(ctx *gin.Context)
+		MoveByID(ctx *gin.Context)
+		DeleteByID(ctx *gin.Context)
+	}
+
+	folderController struct {
+		jwtService    config.JWTService
+		folderService service.FolderService
+	}
+)
+
+func NewFolderController(fs service.FolderService, jwt config.JWTService) FolderController {
+	return &folderController{
+		jwtService:    jwt,
+		folderService: fs,
+	}
+}
+
+func abortFolder(ctx *gin.Context, message string, err error) {
+	response := utils.BuildResponseFailed(message, err.Error(), nil)
+	switch err {
+	case dto.ErrFolderNotFound, dto.ErrTeamNotFound:
+		ctx.AbortWithStatusJSON(http.StatusNotFound, response)
+	case dto.ErrUnauthorizedFolderAccess, dto.ErrUnauthorizedTeamAccess:
+		ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+	case dto.ErrFolderNameRequired:
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+	case dto.ErrInvalidFolderMove, dto.ErrTeamMismatch:
+		ctx.AbortWithStatusJSON(http.StatusConflict, response)
+	default:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+	}
+}
+
+func (c *folderController) Create(ctx *gin.Context) {
+	var req dto.CreateFolderRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	res, err := c.folderService.Create(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), req)
+	if err != nil {
+		abortFolder(ctx, dto.MESSAGE_FAILED_CREATE_FOLDER, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_FOLDER, res)
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// GetFolder lists the subfolders of the folder with their breadcrumbs, the
+// root is listed when no ID is given.
+func (c *folderController) GetFolder(ctx *gin.Context) {
+	res, err := c.folderService.GetFolder(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"))
+	if err != nil {
+		abortFolder(ctx, dto.MESSAGE_FAILED_GET_FOLDER, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_FOLDER, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetTeamRoot lists the folders at the root of the team of the path, their
+// content is listed by GetFolder like for any folder.
+func (c *folderController) GetTeamRoot(ctx *gin.Context) {
+	res, err := c.folderService.GetTeamRoot(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"))
+	if err != nil {
+		abortFolder(ctx, dto.MESSAGE_FAILED_GET_FOLDER, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_FOLDER, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *folderController) RenameByID(ctx *gin.Context) {
+	var req dto.FolderUpdate
+	if err := ctx.ShouldBind(&req); err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	res, err := c.folderService.Rename(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"), req)
+	if err != nil {
+		abortFolder(ctx, dto.MESSAGE_FAILED_UPDATE_FOLDER, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_UPDATE_FOLDER, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *folderController) MoveByID(ctx *gin.Context) {
+	var req dto.MoveFolderRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	res, err := c.folderService.Move(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"), req)
+	if err != nil {
+		abortFolder(ctx, dto.MESSAGE_FAILED_MOVE_FOLDER, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_MOVE_FOLDER, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *folderController) DeleteByID(ctx *gin.Context) {
+	if err := c.folderService.Delete(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id")); err != nil {
+		abortFolder(ctx, dto.MESSAGE_FAILED_DELETE_FOLDER, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_DELETE_FOLDER, nil)
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/service"
+	"FP-DevOps/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	GrantController interface {
+		Grant(ctx *gin.Context)
+		GetGrants(ctx *gin.Context)
+		RevokeByID(ctx *gin.Context)
+		GetSharedWithMe(ctx *gin.Context)
+	}
+
+	grantController struct {
+		jwtService   config.JWTService
+		grantService service.GrantService
+	}
+)
+
+func NewGrantController(gs service.GrantService, jwt config.JWTService) GrantController {
+	return &grantController{
+		jwtService:   jwt,
+		grantService: gs,
+	}
+}
+
+func abortGrant(ctx *gin.Context, message string, err error) {
+	response := utils.BuildResponseFailed(message, err.Error(), nil)
+	switch err {
+	case dto.ErrFileNotFound, dto.ErrGrantNotFound, dto.ErrGranteeNotFound:
+		ctx.AbortWithStatusJSON(http.StatusNotFound, response)
+	case dto.ErrUnauthorizedFileAccess:
+		ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+	case dto.ErrInvalidPermission, dto.ErrInvalidGrantee:
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+	default:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+	}
+}
+
+func (c *grantController) Grant(ctx *gin.Context) {
+	var req dto.GrantFileRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	res, err := c.grantService.Grant(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"), req)
+	if err != nil {
+		abortGrant(ctx, dto.MESSAGE_FAILED_GRANT_FILE, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GRANT_FILE, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *grantController) GetGrants(ctx *gin.Context) {
+	res, err := c.grantService.GetGrants(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"))
+	if err != nil {
+		abortGrant(ctx, dto.MESSAGE_FAILED_GET_GRANTS, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_GRANTS, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *grantController) RevokeByID(ctx *gin.Context) {
+	if err := c.grantService.Revoke(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"), ctx.Param("grant")); err != nil {
+		abortGrant(ctx, dto.MESSAGE_FAILED_REVOKE_GRANT, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_REVOKE_GRANT, nil)
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetSharedWithMe lists the files other users shared with the user.
+func (c *grantController) GetSharedWithMe(ctx *gin.Context) {
+	var req dto.PaginationQuery
+	if err := ctx.ShouldBind(&req); err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	result, err := c.grantService.GetSharedWithMe(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), req)
+	if err != nil {
+		abortGrant(ctx, dto.MESSAGE_FAILED_GET_SHARED_FILES, err)
+		return
+	}
+
+	res := utils.Response{
+		Status:  true,
+		Message: dto.MESSAGE_SUCCESS_GET_SHARED_FILES,
+		Data:    result.Data,
+		Meta:    result.PaginationMetadata,
+	}
+	ctx.JSON(http.StatusOK, res)
+}
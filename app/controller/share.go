@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/service"
+	"FP-DevOps/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	ShareController interface {
+		Create(ctx *gin.Context)
+		GetByFile(ctx *gin.Context)
+		RevokeByID(ctx *gin.Context)
+		Open(ctx *gin.Context)
+	}
+
+	shareController struct {
+		jwtService   config.JWTService
+		shareService service.ShareService
+	}
+)
+
+func NewShareController(ss service.ShareService, jwt config.JWTService) ShareController {
+	return &shareController{
+		jwtService:   jwt,
+		shareService: ss,
+	}
+}
+
+func abortShare(ctx *gin.Context, message string, err error) {
+	response := utils.BuildResponseFailed(message, err.Error(), nil)
+	switch err {
+	case dto.ErrFileNotFound, dto.ErrShareLinkNotFound:
+		ctx.AbortWithStatusJSON(http.StatusNotFound, response)
+	case dto.ErrUnauthorizedFileAccess, dto.ErrUnauthorizedShareLinkAccess:
+		ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+	case dto.ErrInvalidShareLink:
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+	case dto.ErrShareLinkExpired, dto.ErrShareLinkRevoked, dto.ErrShareLinkDownloadLimit:
+		ctx.AbortWithStatusJSON(http.StatusGone, response)
+	default:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+	}
+}
+
+func (c *shareController) Create(ctx *gin.Context) {
+	var req dto.CreateShareLinkRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	res, err := c.shareService.Create(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"), req)
+	if err != nil {
+		abortShare(ctx, dto.MESSAGE_FAILED_CREATE_SHARE_LINK, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_SHARE_LINK, res)
+	ctx.JSON(http.StatusCreated, response)
+}
+
+func (c *shareController) GetByFile(ctx *gin.Context) {
+	res, err := c.shareService.GetByFile(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"))
+	if err != nil {
+		abortShare(ctx, dto.MESSAGE_FAILED_GET_SHARE_LINKS, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_SHARE_LINKS, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *shareController) RevokeByID(ctx *gin.Context) {
+	res, err := c.shareService.Revoke(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"))
+	if err != nil {
+		abortShare(ctx, dto.MESSAGE_FAILED_REVOKE_SHARE_LINK, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_REVOKE_SHARE_LINK, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Open serves the file of a share link. Links with a password show a form
+// posting it back to the same URL.
+func (c *shareController) Open(ctx *gin.Context) {
+	// every request returning content counts, ranges included, as any range
+	// can be the whole file; only HEAD requests are free
+	download := ctx.Request.Method != http.MethodHead
+
+	res, err := c.shareService.Open(ctx.Request.Context(), ctx.Param("token"), ctx.PostForm("password"), download)
+	if err != nil {
+		if err == dto.ErrShareLinkPasswordRequired || err == dto.ErrInvalidShareLinkPassword {
+			data := gin.H{"title": "Password Required"}
+			if err == dto.ErrInvalidShareLinkPassword {
+				data["message"] = err.Error()
+			}
+			ctx.HTML(http.StatusUnauthorized, "sharePassword.tmpl", data)
+			return
+		}
+		abortShare(ctx, dto.MESSAGE_FAILED_GET_FILE, err)
+		return
+	}
+	defer res.Content.Close()
+
+	serveFile(ctx, res, ctx.Query("view") != "")
+}
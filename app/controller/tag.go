@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/service"
+	"FP-DevOps/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	TagController interface {
+		GetTags(ctx *gin.Context)
+		AddToFile(ctx *gin.Context)
+		RemoveFromFile(ctx *gin.Context)
+		DeleteByID(ctx *gin.Context)
+	}
+
+	tagController struct {
+		jwtService config.JWTService
+		tagService service.TagService
+	}
+)
+
+func NewTagController(ts service.TagService, jwt config.JWTService) TagController {
+	return &tagController{
+		jwtService: jwt,
+		tagService: ts,
+	}
+}
+
+func abortTag(ctx *gin.Context, message string, err error) {
+	response := utils.BuildResponseFailed(message, err.Error(), nil)
+	switch err {
+	case dto.ErrFileNotFound, dto.ErrTagNotFound:
+		ctx.AbortWithStatusJSON(http.StatusNotFound, response)
+	case dto.ErrUnauthorizedFileAccess, dto.ErrUnauthorizedTagAccess:
+		ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+	case dto.ErrInvalidTagName, dto.ErrTooManyTags:
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+	default:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+	}
+}
+
+// GetTags lists the tags of the user with the number of files they are on.
+func (c *tagController) GetTags(ctx *gin.Context) {
+	res, err := c.tagService.GetTags(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID))
+	if err != nil {
+		abortTag(ctx, dto.MESSAGE_FAILED_GET_TAGS, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_TAGS, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *tagController) AddToFile(ctx *gin.Context) {
+	var req dto.AddTagsRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	res, err := c.tagService.AddToFile(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"), req)
+	if err != nil {
+		abortTag(ctx, dto.MESSAGE_FAILED_ADD_TAGS, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_ADD_TAGS, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *tagController) RemoveFromFile(ctx *gin.Context) {
+	res, err := c.tagService.RemoveFromFile(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"), ctx.Param("tag"))
+	if err != nil {
+		abortTag(ctx, dto.MESSAGE_FAILED_REMOVE_TAG, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_REMOVE_TAG, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *tagController) DeleteByID(ctx *gin.Context) {
+	if err := c.tagService.Delete(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id")); err != nil {
+		abortTag(ctx, dto.MESSAGE_FAILED_DELETE_TAG, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_DELETE_TAG, nil)
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/service"
+	"FP-DevOps/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	TeamController interface {
+		Create(ctx *gin.Context)
+		GetTeams(ctx *gin.Context)
+		GetTeam(ctx *gin.Context)
+		DeleteByID(ctx *gin.Context)
+		Invite(ctx *gin.Context)
+		GetInvitations(ctx *gin.Context)
+		AcceptInvitation(ctx *gin.Context)
+		DeclineInvitation(ctx *gin.Context)
+		UpdateMember(ctx *gin.Context)
+		RemoveMember(ctx *gin.Context)
+	}
+
+	teamController struct {
+		jwtService  config.JWTService
+		teamService service.TeamService
+	}
+)
+
+func NewTeamController(ts service.TeamService, jwt config.JWTService) TeamController {
+	return &teamController{
+		jwtService:  jwt,
+		teamService: ts,
+	}
+}
+
+func abortTeam(ctx *gin.Context, message string, err error) {
+	response := utils.BuildResponseFailed(message, err.Error(), nil)
+	switch err {
+	case dto.ErrTeamNotFound, dto.ErrInviteeNotFound, dto.ErrInvitationNotFound, dto.ErrTeamMemberNotFound:
+		ctx.AbortWithStatusJSON(http.StatusNotFound, response)
+	case dto.ErrUnauthorizedTeamAccess:
+		ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+	case dto.ErrTeamNameRequired, dto.ErrInvalidTeamRole:
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+	case dto.ErrAlreadyTeamMember, dto.ErrLastTeamOwner, dto.ErrTeamNotEmpty:
+		ctx.AbortWithStatusJSON(http.StatusConflict, response)
+	default:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+	}
+}
+
+func (c *teamController) Create(ctx *gin.Context) {
+	var req dto.CreateTeamRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	res, err := c.teamService.Create(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), req)
+	if err != nil {
+		abortTeam(ctx, dto.MESSAGE_FAILED_CREATE_TEAM, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_TEAM, res)
+	ctx.JSON(http.StatusCreated, response)
+}
+
+func (c *teamController) GetTeams(ctx *gin.Context) {
+	res, err := c.teamService.GetTeams(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID))
+	if err != nil {
+		abortTeam(ctx, dto.MESSAGE_FAILED_GET_TEAM, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_TEAM, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetTeam returns a team of the user with its members.
+func (c *teamController) GetTeam(ctx *gin.Context) {
+	res, err := c.teamService.GetTeam(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"))
+	if err != nil {
+		abortTeam(ctx, dto.MESSAGE_FAILED_GET_TEAM, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_TEAM, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *teamController) DeleteByID(ctx *gin.Context) {
+	if err := c.teamService.Delete(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id")); err != nil {
+		abortTeam(ctx, dto.MESSAGE_FAILED_DELETE_TEAM, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_DELETE_TEAM, nil)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *teamController) Invite(ctx *gin.Context) {
+	var req dto.InviteTeamMemberRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	res, err := c.teamService.Invite(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"), req)
+	if err != nil {
+		abortTeam(ctx, dto.MESSAGE_FAILED_INVITE_TEAM_MEMBER, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_INVITE_TEAM_MEMBER, res)
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// GetInvitations lists the invitations the user has not answered yet.
+func (c *teamController) GetInvitations(ctx *gin.Context) {
+	res, err := c.teamService.GetInvitations(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID))
+	if err != nil {
+		abortTeam(ctx, dto.MESSAGE_FAILED_GET_INVITATIONS, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_INVITATIONS, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *teamController) AcceptInvitation(ctx *gin.Context) {
+	res, err := c.teamService.AcceptInvitation(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"))
+	if err != nil {
+		abortTeam(ctx, dto.MESSAGE_FAILED_ACCEPT_INVITATION, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_ACCEPT_INVITATION, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *teamController) DeclineInvitation(ctx *gin.Context) {
+	if err := c.teamService.DeclineInvitation(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id")); err != nil {
+		abortTeam(ctx, dto.MESSAGE_FAILED_DECLINE_INVITATION, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_DECLINE_INVITATION, nil)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *teamController) UpdateMember(ctx *gin.Context) {
+	var req dto.UpdateTeamMemberRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	res, err := c.teamService.UpdateMember(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"), ctx.Param("user"), req)
+	if err != nil {
+		abortTeam(ctx, dto.MESSAGE_FAILED_UPDATE_TEAM_MEMBER, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_UPDATE_TEAM_MEMBER, res)
+	ctx.JSON(http.StatusOK, response)
+}
+
+// RemoveMember removes a member from the team, or lets the user leave it
+// when the member is the user themselves.
+func (c *teamController) RemoveMember(ctx *gin.Context) {
+	if err := c.teamService.RemoveMember(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"), ctx.Param("user")); err != nil {
+		abortTeam(ctx, dto.MESSAGE_FAILED_REMOVE_TEAM_MEMBER, err)
+		return
+	}
+
+	response := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_REMOVE_TEAM_MEMBER, nil)
+	ctx.JSON(http.StatusOK, response)
+}
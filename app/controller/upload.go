@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/service"
+	"FP-DevOps/utils"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	// UploadController implements the core, creation and expiration parts of
+	// the tus 1.0 resumable upload protocol, see https://tus.io/protocols/resumable-upload
+	UploadController interface {
+		Options(ctx *gin.Context)
+		Create(ctx *gin.Context)
+		GetOffset(ctx *gin.Context)
+		Append(ctx *gin.Context)
+	}
+
+	uploadController struct {
+		jwtService    config.JWTService
+		uploadService service.UploadService
+	}
+)
+
+func NewUploadController(us service.UploadService, jwt config.JWTService) UploadController {
+	return &uploadController{
+		jwtService:    jwt,
+		uploadService: us,
+	}
+}
+
+// checkTusResumable rejects requests made with a protocol version other than
+// the one we implement.
+func checkTusResumable(ctx *gin.Context, message string) bool {
+	ctx.Header("Tus-Resumable", constants.TUS_VERSION)
+	if ctx.GetHeader("Tus-Resumable") == constants.TUS_VERSION {
+		return true
+	}
+
+	ctx.Header("Tus-Version", constants.TUS_VERSION)
+	response := utils.BuildResponseFailed(message, dto.ErrUnsupportedTusVersion.Error(), nil)
+	ctx.AbortWithStatusJSON(http.StatusPreconditionFailed, response)
+	return false
+}
+
+// parseUploadMetadata decodes an Upload-Metadata header, a comma separated list
+// of keys each optionally followed by a space and its base64 encoded value.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, dto.ErrInvalidUploadMetadata
+		}
+
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, dto.ErrInvalidUploadMetadata
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+func setUploadHeaders(ctx *gin.Context, res dto.UploadResponse) {
+	ctx.Header("Upload-Offset", strconv.FormatInt(res.Offset, 10))
+	ctx.Header("Upload-Length", strconv.FormatInt(res.Length, 10))
+	ctx.Header("Upload-Expires", res.ExpiresAt.UTC().Format(http.TimeFormat))
+	if res.FileID != "" {
+		ctx.Header("File-Id", res.FileID)
+	}
+}
+
+func abortUpload(ctx *gin.Context, message string, err error) {
+	response := utils.BuildResponseFailed(message, err.Error(), nil)
+	switch err {
+	case dto.ErrUploadNotFound, dto.ErrFolderNotFound:
+		ctx.AbortWithStatusJSON(http.StatusNotFound, response)
+	case dto.ErrUploadExpired:
+		ctx.AbortWithStatusJSON(http.StatusGone, response)
+	case dto.ErrUnauthorizedUploadAccess, dto.ErrUnauthorizedFolderAccess:
+		ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+	case dto.ErrUploadOffsetMismatch:
+		ctx.AbortWithStatusJSON(http.StatusConflict, response)
+	case dto.ErrFileSizeExceeded, dto.ErrUploadLengthExceeded:
+		ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, response)
+	case dto.ErrStorageQuotaExceeded:
+		ctx.AbortWithStatusJSON(http.StatusInsufficientStorage, response)
+	default:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+	}
+}
+
+func (c *uploadController) Options(ctx *gin.Context) {
+	ctx.Header("Tus-Resumable", constants.TUS_VERSION)
+	ctx.Header("Tus-Version", constants.TUS_VERSION)
+	ctx.Header("Tus-Extension", constants.TUS_EXTENSIONS)
+	ctx.Header("Tus-Max-Size", strconv.FormatInt(c.uploadService.MaxSize(), 10))
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *uploadController) Create(ctx *gin.Context) {
+	if !checkTusResumable(ctx, dto.MESSAGE_FAILED_CREATE_UPLOAD) {
+		return
+	}
+
+	length, err := strconv.ParseInt(ctx.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_UPLOAD, dto.ErrUploadLengthRequired.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	metadata, err := parseUploadMetadata(ctx.GetHeader("Upload-Metadata"))
+	if err != nil {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_UPLOAD, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	filename := metadata["filename"]
+	if filename == "" {
+		filename = metadata["name"]
+	}
+	if filename == "" {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_UPLOAD, dto.ErrFileRequired.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	req := dto.CreateUploadRequest{
+		Filename: filename,
+		FolderID: metadata["folder_id"],
+		Length:   length,
+	}
+
+	res, err := c.uploadService.Create(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), req)
+	if err != nil {
+		abortUpload(ctx, dto.MESSAGE_FAILED_CREATE_UPLOAD, err)
+		return
+	}
+
+	setUploadHeaders(ctx, res)
+	ctx.Header("Location", strings.TrimSuffix(ctx.Request.URL.Path, "/")+"/"+res.ID)
+	ctx.Status(http.StatusCreated)
+}
+
+func (c *uploadController) GetOffset(ctx *gin.Context) {
+	if !checkTusResumable(ctx, dto.MESSAGE_FAILED_GET_UPLOAD) {
+		return
+	}
+
+	res, err := c.uploadService.Get(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"))
+	if err != nil {
+		abortUpload(ctx, dto.MESSAGE_FAILED_GET_UPLOAD, err)
+		return
+	}
+
+	setUploadHeaders(ctx, res)
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Status(http.StatusOK)
+}
+
+func (c *uploadController) Append(ctx *gin.Context) {
+	if !checkTusResumable(ctx, dto.MESSAGE_FAILED_APPEND_UPLOAD) {
+		return
+	}
+
+	if ctx.ContentType() != "application/offset+octet-stream" {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_APPEND_UPLOAD, dto.ErrInvalidUploadContentType.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusUnsupportedMediaType, response)
+		return
+	}
+
+	offset, err := strconv.ParseInt(ctx.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_APPEND_UPLOAD, dto.ErrUploadOffsetRequired.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+
+	req := dto.AppendUploadRequest{
+		Offset:  offset,
+		Size:    ctx.Request.ContentLength,
+		Content: ctx.Request.Body,
+	}
+
+	res, err := c.uploadService.Append(ctx.Request.Context(), ctx.GetString(constants.CTX_KEY_USER_ID), ctx.Param("id"), req)
+	if err != nil {
+		abortUpload(ctx, dto.MESSAGE_FAILED_APPEND_UPLOAD, err)
+		return
+	}
+
+	setUploadHeaders(ctx, res)
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"errors"
+	"io"
+)
+
+const (
+	MESSAGE_FAILED_CREATE_ARCHIVE  = "failed create archive"
+	MESSAGE_FAILED_EXTRACT_ARCHIVE = "failed extract archive"
+
+	MESSAGE_SUCCESS_EXTRACT_ARCHIVE = "success extract archive"
+)
+
+var (
+	ErrArchiveEmpty    = errors.New("no files selected for the archive")
+	ErrArchiveTooLarge = errors.New("too many files for one archive")
+
+	ErrUnsupportedArchive  = errors.New("only .zip and .tar.gz archives can be extracted")
+	ErrInvalidArchive      = errors.New("archive is corrupted")
+	ErrUnsafeArchivePath   = errors.New("archive contains a path outside of its root")
+	ErrArchiveExtractLimit = errors.New("archive expands beyond the extraction limits")
+)
+
+type (
+	CreateArchiveRequest struct {
+		FileIDs   []string `json:"file_ids" form:"file_ids"`
+		FolderIDs []string `json:"folder_ids" form:"folder_ids"`
+	}
+
+	ExtractArchiveRequest struct {
+		Filename string
+		// FolderID is where the archive is extracted, empty for the root.
+		FolderID string
+		Content  io.Reader
+	}
+
+	ArchiveEntry struct {
+		FileID string
+		// Name is the path of the file inside the archive.
+		Name string
+	}
+
+	ArchiveResponse struct {
+		Filename string
+		Entries  []ArchiveEntry
+	}
+)
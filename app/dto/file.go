@@ -2,7 +2,8 @@ package dto
 
 import (
 	"errors"
-	"mime/multipart"
+	"io"
+	"time"
 )
 
 const (
@@ -10,22 +11,57 @@ const (
 	MESSAGE_FAILED_UPDATE_FILE = "failed update file"
 	MESSAGE_FAILED_DELETE_FILE = "failed delete file"
 	MESSAGE_FAILED_GET_FILE    = "failed get file"
+	MESSAGE_FAILED_MOVE_FILE   = "failed move file"
 
-	MESSAGE_SUCCESS_CREATE_FILE = "success create file"
-	MESSAGE_SUCCESS_UPDATE_FILE = "success update file"
-	MESSAGE_SUCCESS_DELETE_FILE = "success delete file"
-	MESSAGE_SUCCESS_GET_FILE    = "success get file"
+	MESSAGE_FAILED_CREATE_FILE_VERSION  = "failed create file version"
+	MESSAGE_FAILED_GET_FILE_VERSION     = "failed get file version"
+	MESSAGE_FAILED_RESTORE_FILE_VERSION = "failed restore file version"
+
+	MESSAGE_FAILED_GET_TRASH    = "failed get trash"
+	MESSAGE_FAILED_RESTORE_FILE = "failed restore file"
+
+	MESSAGE_FAILED_GET_THUMBNAIL = "failed get thumbnail"
+	MESSAGE_THUMBNAIL_PENDING    = "thumbnail is being generated"
+
+	MESSAGE_SUCCESS_CREATE_FILE  = "success create file"
+	MESSAGE_SUCCESS_CREATE_FILES = "success create files"
+	MESSAGE_SUCCESS_UPDATE_FILE  = "success update file"
+	MESSAGE_SUCCESS_DELETE_FILE  = "success delete file"
+	MESSAGE_SUCCESS_GET_FILE     = "success get file"
+	MESSAGE_SUCCESS_MOVE_FILE    = "success move file"
+
+	MESSAGE_SUCCESS_CREATE_FILE_VERSION  = "success create file version"
+	MESSAGE_SUCCESS_GET_FILE_VERSION     = "success get file version"
+	MESSAGE_SUCCESS_RESTORE_FILE_VERSION = "success restore file version"
+
+	MESSAGE_SUCCESS_GET_TRASH    = "success get trash"
+	MESSAGE_SUCCESS_RESTORE_FILE = "success restore file"
 )
 
 var (
-	ErrFileSizeExceeded       = errors.New("file size exceeds the limit of 20MB")
+	ErrFileRequired           = errors.New("file is required")
+	ErrFileSizeExceeded       = errors.New("file size exceeds the upload limit")
+	ErrStorageQuotaExceeded   = errors.New("storage quota exceeded")
 	ErrFileNotFound           = errors.New("file not found")
+	ErrTooManyFiles           = errors.New("too many files in one upload")
 	ErrUnauthorizedFileAccess = errors.New("unauthorized file access, you can only access your own files")
+	ErrFileVersionNotFound    = errors.New("file version not found")
+	ErrThumbnailNotFound      = errors.New("no thumbnail available for this file")
+	ErrThumbnailPending       = errors.New("thumbnail is not generated yet")
+	ErrInvalidThumbnailSize   = errors.New("invalid thumbnail size")
 )
 
 type (
 	CreateFileRequest struct {
-		File *multipart.FileHeader `json:"file" form:"file" binding:"required"`
+		// ID is optional, a new one is generated when it is empty.
+		ID       string
+		Filename string
+		// FolderID is empty for files created at the root.
+		FolderID string
+		// TeamID creates the file at the root of a team, files created in a
+		// folder of a team belong to that team anyway.
+		TeamID  string
+		Content io.Reader
 	}
 
 	FileUpdate struct {
@@ -33,14 +69,49 @@ type (
 		Shareable *bool  `json:"shareable" form:"shareable"`
 	}
 
+	MoveFileRequest struct {
+		// FolderID is empty to move the file to the root.
+		FolderID string `json:"folder_id" form:"folder_id"`
+	}
+
 	FileResponse struct {
-		ID        string `json:"id" form:"id"`
-		Filename  string `json:"filename" form:"filename"`
-		Size      int64  `json:"size" form:"size"`
-		MimeType  string `json:"mime_type" form:"mime_type"`
-		Shareable *bool  `json:"shareable" form:"shareable"`
+		ID        string  `json:"id" form:"id"`
+		Filename  string  `json:"filename" form:"filename"`
+		Size      int64   `json:"size" form:"size"`
+		MimeType  string  `json:"mime_type" form:"mime_type"`
+		Checksum  string  `json:"checksum" form:"checksum"`
+		Shareable *bool   `json:"shareable" form:"shareable"`
+		FolderID  *string `json:"folder_id" form:"folder_id"`
+		// TeamID is only set for files of a team.
+		TeamID  *string  `json:"team_id,omitempty" form:"team_id"`
+		Version int      `json:"version" form:"version"`
+		Tags    []string `json:"tags,omitempty" form:"tags"`
+		// DeletedAt is only set for files in the trash.
+		DeletedAt *time.Time `json:"deleted_at,omitempty" form:"deleted_at"`
+
+		// ModTime and Content are only set when downloading a file. The caller
+		// is responsible for closing Content.
+		ModTime time.Time         `json:"-"`
+		Content io.ReadSeekCloser `json:"-"`
+	}
+
+	// FileUploadResult is the outcome of one of the files uploaded together,
+	// with the HTTP status it would have had on its own.
+	FileUploadResult struct {
+		Filename string        `json:"filename"`
+		Status   int           `json:"status"`
+		File     *FileResponse `json:"file,omitempty"`
+		Error    string        `json:"error,omitempty"`
+		Err      error         `json:"-"`
+	}
 
-		Content []byte `json:"content,omitempty" form:"content,omitempty"`
+	FileVersionResponse struct {
+		Version    int       `json:"version"`
+		Size       int64     `json:"size"`
+		MimeType   string    `json:"mime_type"`
+		Checksum   string    `json:"checksum"`
+		ModifiedAt time.Time `json:"modified_at"`
+		Current    bool      `json:"current"`
 	}
 
 	FilePaginationResponse struct {
@@ -0,0 +1,61 @@
+package dto
+
+import "errors"
+
+const (
+	MESSAGE_FAILED_CREATE_FOLDER = "failed create folder"
+	MESSAGE_FAILED_UPDATE_FOLDER = "failed update folder"
+	MESSAGE_FAILED_MOVE_FOLDER   = "failed move folder"
+	MESSAGE_FAILED_DELETE_FOLDER = "failed delete folder"
+	MESSAGE_FAILED_GET_FOLDER    = "failed get folder"
+
+	MESSAGE_SUCCESS_CREATE_FOLDER = "success create folder"
+	MESSAGE_SUCCESS_UPDATE_FOLDER = "success update folder"
+	MESSAGE_SUCCESS_MOVE_FOLDER   = "success move folder"
+	MESSAGE_SUCCESS_DELETE_FOLDER = "success delete folder"
+	MESSAGE_SUCCESS_GET_FOLDER    = "success get folder"
+)
+
+var (
+	ErrFolderNameRequired       = errors.New("folder name is required")
+	ErrFolderNotFound           = errors.New("folder not found")
+	ErrUnauthorizedFolderAccess = errors.New("unauthorized folder access, you can only access your own folders and those of your teams")
+	ErrInvalidFolderMove        = errors.New("a folder cannot be moved into itself or one of its subfolders")
+)
+
+type (
+	CreateFolderRequest struct {
+		Name string `json:"name" form:"name" binding:"required"`
+		// ParentID is empty for folders created at the root.
+		ParentID string `json:"parent_id" form:"parent_id"`
+		// TeamID creates the folder at the root of a team, folders created
+		// in a folder of a team belong to that team anyway.
+		TeamID string `json:"team_id" form:"team_id"`
+	}
+
+	FolderUpdate struct {
+		Name string `json:"name" form:"name" binding:"required"`
+	}
+
+	MoveFolderRequest struct {
+		// ParentID is empty to move the folder to the root.
+		ParentID string `json:"parent_id" form:"parent_id"`
+	}
+
+	FolderResponse struct {
+		ID       string  `json:"id"`
+		Name     string  `json:"name"`
+		ParentID *string `json:"parent_id"`
+		// TeamID is nil for folders of the user's own storage.
+		TeamID *string `json:"team_id"`
+	}
+
+	// FolderContentResponse lists the subfolders of a folder, the files it
+	// contains are listed by GET /api/file?folder_id=. Folder is nil and
+	// Breadcrumbs is empty for the root, of the user or of a team.
+	FolderContentResponse struct {
+		Folder      *FolderResponse  `json:"folder"`
+		Breadcrumbs []FolderResponse `json:"breadcrumbs"`
+		Folders     []FolderResponse `json:"folders"`
+	}
+)
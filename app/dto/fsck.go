@@ -0,0 +1,41 @@
+package dto
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidFsckMode = errors.New("fsck mode must be dry-run, quarantine or delete")
+)
+
+type (
+	FsckOptions struct {
+		// Mode is one of the constants.ENUM_FSCK_MODE_* values.
+		Mode string
+		// GracePeriod leaves alone objects and blobs changed more recently, as
+		// they may belong to an upload in progress.
+		GracePeriod time.Duration
+	}
+
+	FsckRefCount struct {
+		Checksum string `json:"checksum"`
+		RefCount int64  `json:"ref_count"`
+		Actual   int64  `json:"actual"`
+	}
+
+	FsckReport struct {
+		Objects int `json:"objects"`
+		// OrphanObjects are stored objects nothing references.
+		OrphanObjects []string `json:"orphan_objects"`
+		// MissingObjects are referenced by rows but absent from storage.
+		MissingObjects []string `json:"missing_objects"`
+		// RefCountMismatches are blobs whose reference count differs from the
+		// number of files and versions using them.
+		RefCountMismatches []FsckRefCount `json:"ref_count_mismatches"`
+
+		Quarantined int `json:"quarantined"`
+		Deleted     int `json:"deleted"`
+		Fixed       int `json:"fixed"`
+	}
+)
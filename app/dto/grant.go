@@ -0,0 +1,51 @@
+package dto
+
+import "errors"
+
+const (
+	MESSAGE_FAILED_GRANT_FILE        = "failed grant file access"
+	MESSAGE_FAILED_GET_GRANTS        = "failed get file grants"
+	MESSAGE_FAILED_REVOKE_GRANT      = "failed revoke file access"
+	MESSAGE_FAILED_GET_SHARED_FILES  = "failed get shared files"
+	MESSAGE_SUCCESS_GRANT_FILE       = "success grant file access"
+	MESSAGE_SUCCESS_GET_GRANTS       = "success get file grants"
+	MESSAGE_SUCCESS_REVOKE_GRANT     = "success revoke file access"
+	MESSAGE_SUCCESS_GET_SHARED_FILES = "success get shared files"
+)
+
+var (
+	ErrInvalidPermission = errors.New("invalid permission, use view, edit or manage")
+	ErrInvalidGrantee    = errors.New("a file cannot be shared with its owner or yourself")
+	ErrGranteeNotFound   = errors.New("user to share with not found")
+	ErrGrantNotFound     = errors.New("file grant not found")
+)
+
+type (
+	// GrantFileRequest shares a file with the user of that name, granting it
+	// again changes the permission.
+	GrantFileRequest struct {
+		Username   string `json:"username" form:"username" binding:"required"`
+		Permission string `json:"permission" form:"permission" binding:"required"`
+	}
+
+	FileGrantResponse struct {
+		ID         string `json:"id"`
+		FileID     string `json:"file_id"`
+		UserID     string `json:"user_id"`
+		Username   string `json:"username"`
+		Permission string `json:"permission"`
+	}
+
+	// SharedFileResponse is a file another user shared, with who owns it
+	// and what can be done with it.
+	SharedFileResponse struct {
+		FileResponse
+		Owner      string `json:"owner"`
+		Permission string `json:"permission"`
+	}
+
+	SharedFilePaginationResponse struct {
+		Data []SharedFileResponse `json:"data"`
+		PaginationMetadata
+	}
+)
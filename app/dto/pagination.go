@@ -1,10 +1,57 @@
 package dto
 
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort, use name, size, created_at, updated_at or mime_type ordered asc or desc")
+	ErrInvalidFilter = errors.New("invalid filter, sizes must be positive bytes and dates RFC 3339 or YYYY-MM-DD with the lower bound first")
+)
+
 type (
 	PaginationQuery struct {
 		Search  string `form:"search"`
 		Page    int    `form:"page"`
 		PerPage int    `form:"per_page"`
+		// FolderID lists a single folder, "root" for the files outside of
+		// any folder. All files are listed when it is empty.
+		FolderID string `form:"folder_id"`
+		// Tag only lists the files with the tag of that name.
+		Tag string `form:"tag"`
+		// TeamID lists the files of a team instead of the user's own, it is
+		// taken from the path of the team's listing.
+		TeamID string `form:"-"`
+
+		Sort  string `form:"sort"`
+		Order string `form:"order"`
+		// MimeType is a prefix, "image/" lists every kind of image.
+		MimeType string `form:"mime_type"`
+		MinSize  *int64 `form:"min_size"`
+		MaxSize  *int64 `form:"max_size"`
+		// CreatedAfter is inclusive and CreatedBefore exclusive, both are
+		// RFC 3339 times or YYYY-MM-DD dates in UTC.
+		CreatedAfter  string `form:"created_after"`
+		CreatedBefore string `form:"created_before"`
+		Shareable     *bool  `form:"shareable"`
+	}
+
+	// FileFilter is a validated PaginationQuery the file listing is queried
+	// with, Sort is the column to order by. Unset fields do not filter.
+	FileFilter struct {
+		Search        string
+		FolderID      string
+		TeamID        string
+		Tag           string
+		MimeType      string
+		MinSize       *int64
+		MaxSize       *int64
+		CreatedAfter  *time.Time
+		CreatedBefore *time.Time
+		Shareable     *bool
+		Sort          string
+		Desc          bool
 	}
 
 	PaginationMetadata struct {
@@ -0,0 +1,28 @@
+package dto
+
+import "errors"
+
+const (
+	MESSAGE_FAILED_GET_PREVIEW = "failed get preview"
+)
+
+var (
+	ErrPreviewUnsupported = errors.New("no preview available for this file type")
+	ErrInvalidTable       = errors.New("file is not a valid table")
+)
+
+type (
+	// FilePreviewResponse is a file rendered for the browser. HTML is set for
+	// Markdown and source code and is safe to embed as is, tables come as
+	// one page of rows.
+	FilePreviewResponse struct {
+		File      FileResponse
+		Kind      string
+		HTML      string
+		Header    []string
+		Rows      [][]string
+		Page      int
+		MaxPage   int
+		Truncated bool
+	}
+)
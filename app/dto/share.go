@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	MESSAGE_FAILED_CREATE_SHARE_LINK  = "failed create share link"
+	MESSAGE_FAILED_GET_SHARE_LINKS    = "failed get share links"
+	MESSAGE_FAILED_REVOKE_SHARE_LINK  = "failed revoke share link"
+	MESSAGE_SUCCESS_CREATE_SHARE_LINK = "success create share link"
+	MESSAGE_SUCCESS_GET_SHARE_LINKS   = "success get share links"
+	MESSAGE_SUCCESS_REVOKE_SHARE_LINK = "success revoke share link"
+)
+
+var (
+	ErrShareLinkNotFound           = errors.New("share link not found")
+	ErrUnauthorizedShareLinkAccess = errors.New("unauthorized share link access, you can only manage links to your own files")
+	ErrInvalidShareLink            = errors.New("share links must expire in the future and allow at least one download")
+	ErrShareLinkExpired            = errors.New("share link has expired")
+	ErrShareLinkRevoked            = errors.New("share link has been revoked")
+	ErrShareLinkDownloadLimit      = errors.New("share link has reached its download limit")
+	ErrShareLinkPasswordRequired   = errors.New("share link is protected by a password")
+	ErrInvalidShareLinkPassword    = errors.New("wrong share link password")
+)
+
+type (
+	// CreateShareLinkRequest leaves ExpiresAt and MaxDownloads empty for
+	// links that never expire or run out, and Password for links anyone with
+	// the token can open.
+	CreateShareLinkRequest struct {
+		ExpiresAt    *time.Time `json:"expires_at" form:"expires_at"`
+		Password     string     `json:"password" form:"password"`
+		MaxDownloads *int       `json:"max_downloads" form:"max_downloads"`
+	}
+
+	ShareLinkResponse struct {
+		ID           string     `json:"id"`
+		FileID       string     `json:"file_id"`
+		Token        string     `json:"token"`
+		URL          string     `json:"url"`
+		HasPassword  bool       `json:"has_password"`
+		ExpiresAt    *time.Time `json:"expires_at"`
+		MaxDownloads *int       `json:"max_downloads"`
+		Downloads    int        `json:"downloads"`
+		RevokedAt    *time.Time `json:"revoked_at"`
+		CreatedAt    time.Time  `json:"created_at"`
+	}
+)
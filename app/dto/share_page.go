@@ -0,0 +1,18 @@
+package dto
+
+const (
+	MESSAGE_FAILED_GET_SHARE_PAGE = "failed get share page"
+)
+
+type (
+	// SharePageResponse is what the share page of a file shows. Preview is
+	// empty for files that can only be downloaded, Text holds the beginning
+	// of text files.
+	SharePageResponse struct {
+		File      FileResponse
+		Owner     string
+		Preview   string
+		Text      string
+		Truncated bool
+	}
+)
@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	MESSAGE_FAILED_SIGN_FILE_URL  = "failed sign file url"
+	MESSAGE_SUCCESS_SIGN_FILE_URL = "success sign file url"
+)
+
+var (
+	ErrInvalidSignedURLExpiry = errors.New("signed urls must expire within a week")
+	ErrInvalidURLSignature    = errors.New("invalid url signature")
+	ErrSignedURLExpired       = errors.New("signed url has expired")
+)
+
+type (
+	// SignFileURLRequest leaves ExpiresIn empty for URLs valid for an hour.
+	SignFileURLRequest struct {
+		// ExpiresIn is how many seconds the URL is valid for.
+		ExpiresIn int64 `json:"expires_in" form:"expires_in"`
+	}
+
+	SignedURLResponse struct {
+		URL       string    `json:"url"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+)
@@ -0,0 +1,40 @@
+package dto
+
+import "errors"
+
+const (
+	MESSAGE_FAILED_GET_TAGS    = "failed get tags"
+	MESSAGE_FAILED_ADD_TAGS    = "failed add tags"
+	MESSAGE_FAILED_REMOVE_TAG  = "failed remove tag"
+	MESSAGE_FAILED_DELETE_TAG  = "failed delete tag"
+	MESSAGE_SUCCESS_GET_TAGS   = "success get tags"
+	MESSAGE_SUCCESS_ADD_TAGS   = "success add tags"
+	MESSAGE_SUCCESS_REMOVE_TAG = "success remove tag"
+	MESSAGE_SUCCESS_DELETE_TAG = "success delete tag"
+)
+
+var (
+	ErrInvalidTagName        = errors.New("tag names may only contain letters, digits, spaces, dots, dashes and underscores, up to 50 characters")
+	ErrTooManyTags           = errors.New("too many tags on this file")
+	ErrTagNotFound           = errors.New("tag not found")
+	ErrUnauthorizedTagAccess = errors.New("unauthorized tag access, you can only access your own tags")
+)
+
+type (
+	AddTagsRequest struct {
+		Tags []string `json:"tags" form:"tags" binding:"required"`
+	}
+
+	// TagResponse is a tag with the number of files it is on, files in the
+	// trash are not counted.
+	TagResponse struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Count int64  `json:"count"`
+	}
+
+	FileTagsResponse struct {
+		FileID string   `json:"file_id"`
+		Tags   []string `json:"tags"`
+	}
+)
@@ -0,0 +1,80 @@
+package dto
+
+import "errors"
+
+const (
+	MESSAGE_FAILED_CREATE_TEAM         = "failed create team"
+	MESSAGE_FAILED_GET_TEAM            = "failed get team"
+	MESSAGE_FAILED_DELETE_TEAM         = "failed delete team"
+	MESSAGE_FAILED_INVITE_TEAM_MEMBER  = "failed invite team member"
+	MESSAGE_FAILED_GET_INVITATIONS     = "failed get invitations"
+	MESSAGE_FAILED_ACCEPT_INVITATION   = "failed accept invitation"
+	MESSAGE_FAILED_DECLINE_INVITATION  = "failed decline invitation"
+	MESSAGE_FAILED_UPDATE_TEAM_MEMBER  = "failed update team member"
+	MESSAGE_FAILED_REMOVE_TEAM_MEMBER  = "failed remove team member"
+	MESSAGE_SUCCESS_CREATE_TEAM        = "success create team"
+	MESSAGE_SUCCESS_GET_TEAM           = "success get team"
+	MESSAGE_SUCCESS_DELETE_TEAM        = "success delete team"
+	MESSAGE_SUCCESS_INVITE_TEAM_MEMBER = "success invite team member"
+	MESSAGE_SUCCESS_GET_INVITATIONS    = "success get invitations"
+	MESSAGE_SUCCESS_ACCEPT_INVITATION  = "success accept invitation"
+	MESSAGE_SUCCESS_DECLINE_INVITATION = "success decline invitation"
+	MESSAGE_SUCCESS_UPDATE_TEAM_MEMBER = "success update team member"
+	MESSAGE_SUCCESS_REMOVE_TEAM_MEMBER = "success remove team member"
+)
+
+var (
+	ErrTeamNameRequired       = errors.New("team name is required")
+	ErrTeamNotFound           = errors.New("team not found")
+	ErrUnauthorizedTeamAccess = errors.New("unauthorized team access, your role in the team does not allow this")
+	ErrInvalidTeamRole        = errors.New("invalid team role, use owner, admin, member or viewer")
+	ErrInviteeNotFound        = errors.New("user to invite not found")
+	ErrAlreadyTeamMember      = errors.New("user is already a member of the team")
+	ErrInvitationNotFound     = errors.New("invitation not found")
+	ErrTeamMemberNotFound     = errors.New("team member not found")
+	ErrLastTeamOwner          = errors.New("a team needs at least one owner")
+	ErrTeamNotEmpty           = errors.New("only teams without files and folders can be deleted")
+	ErrTeamMismatch           = errors.New("files and folders cannot be moved between teams and personal storage")
+)
+
+type (
+	CreateTeamRequest struct {
+		Name string `json:"name" form:"name" binding:"required"`
+	}
+
+	InviteTeamMemberRequest struct {
+		Username string `json:"username" form:"username" binding:"required"`
+		Role     string `json:"role" form:"role" binding:"required"`
+	}
+
+	UpdateTeamMemberRequest struct {
+		Role string `json:"role" form:"role" binding:"required"`
+	}
+
+	// TeamResponse is a team with the role of the user asking for it.
+	TeamResponse struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Role string `json:"role"`
+	}
+
+	TeamMemberResponse struct {
+		UserID   string `json:"user_id"`
+		Username string `json:"username"`
+		Role     string `json:"role"`
+	}
+
+	TeamDetailResponse struct {
+		TeamResponse
+		Members []TeamMemberResponse `json:"members"`
+	}
+
+	TeamInvitationResponse struct {
+		ID        string `json:"id"`
+		TeamID    string `json:"team_id"`
+		TeamName  string `json:"team_name"`
+		Username  string `json:"username"`
+		Role      string `json:"role"`
+		InvitedBy string `json:"invited_by"`
+	}
+)
@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	MESSAGE_FAILED_CREATE_UPLOAD = "failed create upload"
+	MESSAGE_FAILED_GET_UPLOAD    = "failed get upload"
+	MESSAGE_FAILED_APPEND_UPLOAD = "failed append upload"
+)
+
+var (
+	ErrUnsupportedTusVersion    = errors.New("unsupported tus version, only 1.0.0 is supported")
+	ErrUploadLengthRequired     = errors.New("upload length is required")
+	ErrUploadOffsetRequired     = errors.New("upload offset is required")
+	ErrInvalidUploadMetadata    = errors.New("invalid upload metadata")
+	ErrInvalidUploadContentType = errors.New("content type must be application/offset+octet-stream")
+	ErrUploadNotFound           = errors.New("upload not found")
+	ErrUploadExpired            = errors.New("upload has expired")
+	ErrUploadOffsetMismatch     = errors.New("upload offset does not match the current offset")
+	ErrUploadLengthExceeded     = errors.New("content exceeds the declared upload length")
+	ErrUnauthorizedUploadAccess = errors.New("unauthorized upload access, you can only access your own uploads")
+)
+
+type (
+	CreateUploadRequest struct {
+		Filename string
+		FolderID string
+		Length   int64
+	}
+
+	AppendUploadRequest struct {
+		Offset int64
+		// Size is the number of bytes Content will yield, or -1 when unknown.
+		Size    int64
+		Content io.Reader
+	}
+
+	UploadResponse struct {
+		ID        string    `json:"id"`
+		Filename  string    `json:"filename"`
+		Length    int64     `json:"length"`
+		Offset    int64     `json:"offset"`
+		ExpiresAt time.Time `json:"expires_at"`
+		FileID    string    `json:"file_id,omitempty"`
+	}
+)
@@ -31,7 +31,13 @@ type (
 	}
 
 	UserResponse struct {
-		ID       string `json:"id"`
-		Username string `json:"username"`
+		ID       string        `json:"id"`
+		Username string        `json:"username"`
+		Storage  *StorageUsage `json:"storage,omitempty"`
+	}
+
+	StorageUsage struct {
+		Used  int64 `json:"used"`
+		Limit int64 `json:"limit"`
 	}
 )
@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// Blob is a piece of content stored once under its SHA-256 checksum and shared
+// by every File with the same bytes. RefCount is the number of such files.
+type Blob struct {
+	Checksum string `json:"checksum" form:"checksum" gorm:"primary_key"`
+	Path     string `json:"path" form:"path" gorm:"not null"`
+	Size     int64  `json:"size" form:"size"`
+	RefCount int64  `json:"ref_count" form:"ref_count" gorm:"not null;default:0"`
+
+	CreatedAt time.Time `gorm:"type:timestamp without time zone" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamp without time zone" json:"updated_at"`
+}
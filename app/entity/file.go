@@ -1,6 +1,10 @@
 package entity
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type File struct {
 	ID        uuid.UUID `json:"id" form:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
@@ -8,8 +12,23 @@ type File struct {
 	Path      string    `json:"path" form:"path"`
 	Size      int64     `json:"size" form:"size"`
 	MimeType  string    `json:"mime_type" form:"mime_type"`
+	Checksum  string    `json:"checksum" form:"checksum"`
 	Shareable *bool     `json:"shareable" form:"shareable" gorm:"default:false"`
 
+	// Version counts the contents the file had, ModifiedAt is when the current
+	// one was uploaded. Files from before versioning have a zero ModifiedAt.
+	Version    int       `json:"version" form:"version" gorm:"not null;default:1"`
+	ModifiedAt time.Time `json:"modified_at" form:"modified_at" gorm:"type:timestamp without time zone"`
+
+	FolderID *uuid.UUID `json:"folder_id" form:"folder_id" gorm:"type:uuid;index"`
+	Folder   *Folder    `json:"folder,omitempty" gorm:"foreignKey:FolderID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+
+	// TeamID is set for files belonging to a team, UserID is then the uploader.
+	TeamID *uuid.UUID `json:"team_id" form:"team_id" gorm:"type:uuid;index"`
+	Team   *Team      `json:"team,omitempty" gorm:"foreignKey:TeamID;references:ID;constraint:OnUpdate:CASCADE;"`
+
+	Tags []Tag `json:"tags,omitempty" gorm:"many2many:file_tags;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
 	UserID uuid.UUID `json:"user_id" form:"user_id" gorm:"type:uuid;not null"`
 	User   User      `json:"user" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 
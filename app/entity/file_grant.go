@@ -0,0 +1,19 @@
+package entity
+
+import "github.com/google/uuid"
+
+// FileGrant gives a user other than the owner access to a file. Permission is
+// one of constants.ENUM_PERMISSION_*, each including the ones before it.
+type FileGrant struct {
+	ID uuid.UUID `json:"id" form:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+
+	FileID uuid.UUID `json:"file_id" form:"file_id" gorm:"type:uuid;not null;uniqueIndex:idx_file_grants_file_user"`
+	File   File      `json:"file" gorm:"foreignKey:FileID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	UserID uuid.UUID `json:"user_id" form:"user_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_file_grants_file_user"`
+	User   User      `json:"user" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	Permission string `json:"permission" form:"permission" gorm:"not null"`
+
+	Timestamp
+}
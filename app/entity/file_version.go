@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileVersion is a previous content of a file. It holds the reference on the
+// blob the file held before a newer version replaced it.
+type FileVersion struct {
+	ID       uuid.UUID `json:"id" form:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	FileID   uuid.UUID `json:"file_id" form:"file_id" gorm:"type:uuid;not null;uniqueIndex:idx_file_versions_file_version"`
+	File     File      `json:"file" gorm:"foreignKey:FileID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Version  int       `json:"version" form:"version" gorm:"not null;uniqueIndex:idx_file_versions_file_version"`
+	Path     string    `json:"path" form:"path"`
+	Size     int64     `json:"size" form:"size"`
+	MimeType string    `json:"mime_type" form:"mime_type"`
+	Checksum string    `json:"checksum" form:"checksum"`
+
+	// ModifiedAt is when this content was uploaded, CreatedAt when it was
+	// replaced by a newer version.
+	ModifiedAt time.Time `json:"modified_at" gorm:"type:timestamp without time zone"`
+	CreatedAt  time.Time `json:"created_at" gorm:"type:timestamp without time zone"`
+}
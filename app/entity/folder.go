@@ -0,0 +1,22 @@
+package entity
+
+import "github.com/google/uuid"
+
+// Folder groups files of a user or of a team, folders without a parent are at
+// the root.
+type Folder struct {
+	ID       uuid.UUID  `json:"id" form:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Name     string     `json:"name" form:"name" gorm:"not null"`
+	ParentID *uuid.UUID `json:"parent_id" form:"parent_id" gorm:"type:uuid;index"`
+	Parent   *Folder    `json:"parent,omitempty" gorm:"foreignKey:ParentID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	// TeamID is set for folders belonging to a team, UserID is then who
+	// created them.
+	TeamID *uuid.UUID `json:"team_id" form:"team_id" gorm:"type:uuid;index"`
+	Team   *Team      `json:"team,omitempty" gorm:"foreignKey:TeamID;references:ID;constraint:OnUpdate:CASCADE;"`
+
+	UserID uuid.UUID `json:"user_id" form:"user_id" gorm:"type:uuid;not null;index"`
+	User   User      `json:"user" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	Timestamp
+}
@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ShareLink gives anyone with its token access to a file, until it expires,
+// runs out of downloads or is revoked. Password holds a bcrypt hash, it is
+// empty for links without a password.
+type ShareLink struct {
+	ID    uuid.UUID `json:"id" form:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Token string    `json:"token" form:"token" gorm:"not null;uniqueIndex"`
+
+	FileID uuid.UUID `json:"file_id" form:"file_id" gorm:"type:uuid;not null;index"`
+	File   File      `json:"file" gorm:"foreignKey:FileID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	Password     string     `json:"-" form:"password"`
+	ExpiresAt    *time.Time `json:"expires_at" form:"expires_at" gorm:"type:timestamp without time zone"`
+	MaxDownloads *int       `json:"max_downloads" form:"max_downloads"`
+	Downloads    int        `json:"downloads" form:"downloads" gorm:"not null;default:0"`
+	RevokedAt    *time.Time `json:"revoked_at" form:"revoked_at" gorm:"type:timestamp without time zone"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp without time zone"`
+}
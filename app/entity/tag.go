@@ -0,0 +1,16 @@
+package entity
+
+import "github.com/google/uuid"
+
+// Tag labels files of a user, names are unique per user.
+type Tag struct {
+	ID   uuid.UUID `json:"id" form:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Name string    `json:"name" form:"name" gorm:"not null;uniqueIndex:idx_tags_user_id_name"`
+
+	UserID uuid.UUID `json:"user_id" form:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_tags_user_id_name"`
+	User   User      `json:"user" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	Files []File `json:"files,omitempty" gorm:"many2many:file_tags;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	Timestamp
+}
@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Team is a workspace whose files and folders belong to all of its members,
+// what each member may do depends on their role.
+type Team struct {
+	ID   uuid.UUID `json:"id" form:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Name string    `json:"name" form:"name" gorm:"not null"`
+
+	Timestamp
+}
+
+// TeamMember is a user in a team, Role is one of constants.ENUM_TEAM_ROLE_*.
+type TeamMember struct {
+	ID     uuid.UUID `json:"id" form:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TeamID uuid.UUID `json:"team_id" form:"team_id" gorm:"type:uuid;not null;uniqueIndex:idx_team_members_team_user"`
+	Team   Team      `json:"team" gorm:"foreignKey:TeamID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	UserID uuid.UUID `json:"user_id" form:"user_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_team_members_team_user"`
+	User   User      `json:"user" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Role   string    `json:"role" form:"role" gorm:"not null"`
+
+	Timestamp
+}
+
+// TeamInvitation invites a user to join a team with a role, they become a
+// member once they accept it.
+type TeamInvitation struct {
+	ID     uuid.UUID `json:"id" form:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TeamID uuid.UUID `json:"team_id" form:"team_id" gorm:"type:uuid;not null;uniqueIndex:idx_team_invitations_team_user"`
+	Team   Team      `json:"team" gorm:"foreignKey:TeamID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	UserID uuid.UUID `json:"user_id" form:"user_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_team_invitations_team_user"`
+	User   User      `json:"user" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Role   string    `json:"role" form:"role" gorm:"not null"`
+
+	InvitedByID uuid.UUID `json:"invited_by_id" form:"invited_by_id" gorm:"type:uuid;not null"`
+	InvitedBy   User      `json:"invited_by" gorm:"foreignKey:InvitedByID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp without time zone"`
+}
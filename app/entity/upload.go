@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Upload tracks a resumable (tus) upload. Its content is kept as chunks in
+// storage until Offset reaches Length, after which it is finalized into File.
+type Upload struct {
+	ID        uuid.UUID  `json:"id" form:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Filename  string     `json:"filename" form:"filename"`
+	Length    int64      `json:"length" form:"length"`
+	Offset    int64      `json:"offset" form:"offset"`
+	ExpiresAt time.Time  `json:"expires_at" form:"expires_at" gorm:"type:timestamp without time zone;index"`
+	FileID    *uuid.UUID `json:"file_id" form:"file_id" gorm:"type:uuid"`
+	FolderID  *uuid.UUID `json:"folder_id" form:"folder_id" gorm:"type:uuid"`
+
+	UserID uuid.UUID `json:"user_id" form:"user_id" gorm:"type:uuid;not null"`
+	User   User      `json:"user" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	Timestamp
+}
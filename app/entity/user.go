@@ -12,6 +12,9 @@ type User struct {
 	Username string    `json:"username" form:"username" gorm:"uniqueIndex;not null"`
 	Password string    `json:"password" form:"password"`
 
+	// StorageQuota overrides the default storage quota, in bytes
+	StorageQuota *int64 `json:"storage_quota" form:"storage_quota"`
+
 	Timestamp
 }
 
@@ -1,16 +1,20 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
 	"FP-DevOps/config"
+	"FP-DevOps/constants"
 	"FP-DevOps/controller"
 	"FP-DevOps/middleware"
 	"FP-DevOps/migrations/seeder"
 	"FP-DevOps/repository"
 	"FP-DevOps/routes"
 	"FP-DevOps/service"
+	"FP-DevOps/storage"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/joho/godotenv/autoload"
@@ -21,16 +25,38 @@ func main() {
 	var (
 		db         *gorm.DB          = config.SetUpDatabaseConnection()
 		jwtService config.JWTService = config.NewJWTService()
+		store      storage.Backend   = config.SetUpStorageBackend()
 
-		userRepository repository.UserRepository = repository.NewUserRepository(db)
-		fileRepository repository.FileRepository = repository.NewFileRepository(db)
+		userRepository   repository.UserRepository      = repository.NewUserRepository(db)
+		fileRepository   repository.FileRepository      = repository.NewFileRepository(db, store)
+		folderRepository repository.FolderRepository    = repository.NewFolderRepository(db)
+		uploadRepository repository.UploadRepository    = repository.NewUploadRepository(db, store)
+		tagRepository    repository.TagRepository       = repository.NewTagRepository(db)
+		grantRepository  repository.FileGrantRepository = repository.NewFileGrantRepository(db)
+		teamRepository   repository.TeamRepository      = repository.NewTeamRepository(db)
 
-		userService service.UserService = service.NewUserService(userRepository)
-		fileService service.FileService = service.NewFileService(fileRepository)
+		thumbnailService service.ThumbnailService = service.NewThumbnailService(repository.NewThumbnailRepository(store), fileRepository)
+		authorizer       service.Authorizer       = service.NewAuthorizer(grantRepository, teamRepository)
 
-		userController controller.UserController = controller.NewUserController(userService, jwtService)
-		fileController controller.FileController = controller.NewFileController(fileService, jwtService)
-		viewController controller.ViewController = controller.NewViewController(jwtService)
+		userService   service.UserService   = service.NewUserService(userRepository)
+		fileService   service.FileService   = service.NewFileService(fileRepository, userRepository, folderRepository, authorizer, thumbnailService)
+		folderService service.FolderService = service.NewFolderService(folderRepository, authorizer)
+		uploadService service.UploadService = service.NewUploadService(uploadRepository, fileService)
+		tagService    service.TagService    = service.NewTagService(tagRepository, fileRepository, authorizer)
+		shareService  service.ShareService  = service.NewShareService(repository.NewShareLinkRepository(db), fileRepository, authorizer)
+		grantService  service.GrantService  = service.NewGrantService(grantRepository, fileRepository, userRepository, authorizer)
+		teamService   service.TeamService   = service.NewTeamService(teamRepository, userRepository, authorizer)
+		fsckService   service.FsckService   = service.NewFsckService(repository.NewFsckRepository(db, store))
+
+		userController   controller.UserController   = controller.NewUserController(userService, jwtService)
+		fileController   controller.FileController   = controller.NewFileController(fileService, jwtService)
+		folderController controller.FolderController = controller.NewFolderController(folderService, jwtService)
+		uploadController controller.UploadController = controller.NewUploadController(uploadService, jwtService)
+		tagController    controller.TagController    = controller.NewTagController(tagService, jwtService)
+		shareController  controller.ShareController  = controller.NewShareController(shareService, jwtService)
+		grantController  controller.GrantController  = controller.NewGrantController(grantService, jwtService)
+		teamController   controller.TeamController   = controller.NewTeamController(teamService, jwtService)
+		viewController   controller.ViewController   = controller.NewViewController(jwtService)
 	)
 
 	server := gin.Default()
@@ -38,7 +64,13 @@ func main() {
 	server.LoadHTMLGlob("templates/*")
 
 	routes.User(server, userController, jwtService)
-	routes.File(server, fileController, jwtService)
+	routes.File(server, fileController, uploadController, jwtService)
+	routes.Folder(server, folderController, jwtService)
+	routes.Trash(server, fileController, jwtService)
+	routes.Tag(server, tagController, jwtService)
+	routes.Share(server, shareController, jwtService)
+	routes.Grant(server, grantController, jwtService)
+	routes.Team(server, teamController, fileController, folderController, jwtService)
 	routes.View(server, viewController, jwtService)
 
 	if err := seeder.RunSeeders(db); err != nil {
@@ -46,6 +78,37 @@ func main() {
 		return
 	}
 
+	go thumbnailService.Run(context.Background())
+
+	go func() {
+		for range time.Tick(constants.UPLOAD_PURGE_INTERVAL_IN_MINUTES * time.Minute) {
+			if err := uploadService.PurgeExpired(context.Background()); err != nil {
+				log.Printf("error purging expired uploads: %v", err)
+			}
+		}
+	}()
+
+	if interval := fsckService.Interval(); interval > 0 {
+		go func() {
+			for range time.Tick(interval) {
+				report, err := fsckService.Run(context.Background(), fsckService.Options())
+				if err != nil {
+					log.Printf("error checking storage: %v", err)
+					continue
+				}
+				log.Printf("storage check: %d orphans, %d missing, %d reference count mismatches", len(report.OrphanObjects), len(report.MissingObjects), len(report.RefCountMismatches))
+			}
+		}()
+	}
+
+	go func() {
+		for range time.Tick(constants.TRASH_PURGE_INTERVAL_IN_MINUTES * time.Minute) {
+			if err := fileService.PurgeTrash(context.Background()); err != nil {
+				log.Printf("error purging trash: %v", err)
+			}
+		}
+	}()
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8888"
@@ -11,9 +11,16 @@ func CORSMiddleware() gin.HandlerFunc {
 
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, Range, If-Range, If-None-Match, If-Modified-Since, Tus-Resumable, Upload-Length, Upload-Offset, Upload-Metadata")
+		c.Header("Access-Control-Expose-Headers", "Content-Range, Content-Length, ETag, Location, Tus-Resumable, Tus-Version, Tus-Extension, Tus-Max-Size, Upload-Offset, Upload-Length, Upload-Expires, File-Id")
 		c.Header("Access-Control-Allow-Methods", "POST, HEAD, PATCH, OPTIONS, GET, PUT, DELETE")
 
+		// routes with their own OPTIONS handler (tus discovery) answer themselves
+		if c.Request.Method == http.MethodOptions && c.FullPath() != "" {
+			c.Next()
+			return
+		}
+
 		if c.Request.Method == http.MethodOptions {
 			c.AbortWithStatus(204)
 			return
@@ -21,4 +28,4 @@ func CORSMiddleware() gin.HandlerFunc {
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
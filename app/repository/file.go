@@ -4,36 +4,75 @@ import (
 	"FP-DevOps/constants"
 	"FP-DevOps/dto"
 	"FP-DevOps/entity"
+	"FP-DevOps/storage"
+	"context"
 	"fmt"
+	"io"
+	"log"
 	"math"
-	"os"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type (
 	FileRepository interface {
 		Get(string) (entity.File, error)
-		GetPagination(string, string, int, int) ([]entity.File, int64, int64, error)
-		Create(entity.File) (entity.File, error)
+		GetPagination(string, dto.FileFilter, int, int) ([]entity.File, int64, int64, error)
+		GetByFolders([]string) ([]entity.File, error)
+		Create(entity.File, int64) (entity.File, error)
 		Update(entity.File) (entity.File, error)
+		Move(string, *uuid.UUID) error
 		Delete(string) error
-		DeleteFile(entity.File) error
-		WriteFile(string, string, []byte) (string, error)
-		ReadFile(entity.File) ([]byte, error)
+		GetTrashed(string) (entity.File, error)
+		GetTrashPagination(string, int, int) ([]entity.File, int64, int64, error)
+		GetTrashedBefore(time.Time) ([]entity.File, error)
+		Restore(string) error
+		DeleteFile(context.Context, entity.File) error
+		WriteBlob(context.Context, string, io.Reader, int64) (string, error)
+		ReleaseBlob(context.Context, string) error
+		GetVersions(string) ([]entity.FileVersion, error)
+		GetVersion(string, int) (entity.FileVersion, error)
+		ReplaceContent(string, entity.FileVersion, int64) (entity.File, error)
+		RestoreVersion(string, int) (entity.File, error)
+		PruneVersions(context.Context, string, int) error
+		OpenFile(context.Context, entity.File) (storage.Object, error)
 	}
 
 	fileRepository struct {
-		db *gorm.DB
+		db      *gorm.DB
+		storage storage.Backend
+	}
+
+	// releasedContent collects what releasing content within a transaction
+	// left to delete from storage, which only happens once it committed.
+	releasedContent struct {
+		blobs   []string
+		objects []string
 	}
 )
 
-func NewFileRepository(db *gorm.DB) FileRepository {
+func NewFileRepository(db *gorm.DB, storage storage.Backend) FileRepository {
 	return &fileRepository{
-		db: db,
+		db:      db,
+		storage: storage,
 	}
 }
 
+// objectKey maps a file path to its storage key. Files uploaded before storage
+// backends existed have their path prefixed with the local storage directory.
+func objectKey(path string) string {
+	return strings.TrimPrefix(path, constants.FILE_STORAGE_DIRECTORY+"/")
+}
+
+// blobKey is the storage key of the content with the given SHA-256 checksum.
+func blobKey(checksum string) string {
+	return fmt.Sprintf("%s/%s/%s", constants.BLOB_STORAGE_PREFIX, checksum[:2], checksum)
+}
+
 func (r *fileRepository) Get(fileID string) (entity.File, error) {
 	var file entity.File
 	if err := r.db.Where("id = ?", fileID).First(&file).Error; err != nil {
@@ -43,26 +82,73 @@ func (r *fileRepository) Get(fileID string) (entity.File, error) {
 	return file, nil
 }
 
-func (r *fileRepository) GetPagination(userID, search string, limit, page int) ([]entity.File, int64, int64, error) {
+// likePrefix matches the values starting with prefix, which may contain the
+// wildcards of LIKE.
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix) + "%"
+}
+
+// GetPagination lists the files of a user, or of the team of the filter,
+// matching the filter, with the tags the user put on them. An empty FolderID
+// lists files in every folder, constants.ROOT_FOLDER_ID only the files outside
+// of any folder. The filter's Sort must be a column of files, it is not
+// checked here.
+func (r *fileRepository) GetPagination(userID string, filter dto.FileFilter, limit, page int) ([]entity.File, int64, int64, error) {
 	var files []entity.File
 	var count int64
 
-	if search != "" {
-		err := r.db.Model(&entity.File{}).Where("user_id = ?", userID).Where("filename LIKE ?", "%"+search+"%").Count(&count).Error
-		if err != nil {
-			return nil, 0, 0, err
-		}
+	// the files of a team are listed to each member, otherwise only the
+	// user's own files outside of teams are
+	query := r.db.Model(&entity.File{})
+	if filter.TeamID != "" {
+		query = query.Where("team_id = ?", filter.TeamID)
 	} else {
-		err := r.db.Where("user_id = ?", userID).Model(&entity.File{}).Count(&count).Error
-		if err != nil {
-			return nil, 0, 0, err
-		}
+		query = query.Where("user_id = ? AND team_id IS NULL", userID)
+	}
+	if filter.Search != "" {
+		query = query.Where("filename LIKE ?", "%"+filter.Search+"%")
+	}
+	if filter.FolderID == constants.ROOT_FOLDER_ID {
+		query = query.Where("folder_id IS NULL")
+	} else if filter.FolderID != "" {
+		query = query.Where("folder_id = ?", filter.FolderID)
+	}
+	if filter.Tag != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM file_tags JOIN tags ON tags.id = file_tags.tag_id WHERE file_tags.file_id = files.id AND tags.user_id = ? AND tags.name = ?)", userID, filter.Tag)
+	}
+	if filter.MimeType != "" {
+		query = query.Where("mime_type LIKE ?", likePrefix(filter.MimeType))
+	}
+	if filter.MinSize != nil {
+		query = query.Where("size >= ?", *filter.MinSize)
+	}
+	if filter.MaxSize != nil {
+		query = query.Where("size <= ?", *filter.MaxSize)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.Shareable != nil {
+		query = query.Where("COALESCE(shareable, false) = ?", *filter.Shareable)
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return nil, 0, 0, err
 	}
 
 	maxPage := int64(math.Ceil(float64(count) / float64(limit)))
 	offset := (page - 1) * limit
 
-	err := r.db.Where("user_id = ?", userID).Where("filename LIKE ?", "%"+search+"%").Offset(offset).Limit(limit).Find(&files).Error
+	// files with the same value are ordered by ID, so pages do not overlap
+	err := query.Preload("Tags", func(db *gorm.DB) *gorm.DB {
+		return db.Where("tags.user_id = ?", userID).Order("tags.name")
+	}).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: filter.Sort}, Desc: filter.Desc}).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: filter.Desc}).
+		Offset(offset).Limit(limit).Find(&files).Error
 	if err != nil {
 		return nil, 0, 0, err
 	}
@@ -70,8 +156,24 @@ func (r *fileRepository) GetPagination(userID, search string, limit, page int) (
 	return files, maxPage, count, nil
 }
 
-func (r *fileRepository) Create(file entity.File) (entity.File, error) {
-	if err := r.db.Create(&file).Error; err != nil {
+func (r *fileRepository) GetByFolders(folderIDs []string) ([]entity.File, error) {
+	var files []entity.File
+	if err := r.db.Where("folder_id IN ?", folderIDs).Find(&files).Error; err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// Create saves the file if its size still fits in its owner's quota once the
+// other files being saved concurrently are counted.
+func (r *fileRepository) Create(file entity.File, quota int64) (entity.File, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := reserveQuota(tx, file.UserID.String(), file.Size, quota); err != nil {
+			return err
+		}
+		return tx.Create(&file).Error
+	})
+	if err != nil {
 		return entity.File{}, err
 	}
 
@@ -85,6 +187,11 @@ func (r *fileRepository) Update(file entity.File) (entity.File, error) {
 	return file, nil
 }
 
+func (r *fileRepository) Move(fileID string, folderID *uuid.UUID) error {
+	return r.db.Model(&entity.File{}).Where("id = ?", fileID).Update("folder_id", folderID).Error
+}
+
+// Delete moves the file to the trash, its content is kept until DeleteFile.
 func (r *fileRepository) Delete(fileID string) error {
 	if err := r.db.Where("id = ?", fileID).Delete(&entity.File{}).Error; err != nil {
 		return err
@@ -92,49 +199,346 @@ func (r *fileRepository) Delete(fileID string) error {
 	return nil
 }
 
-func (r *fileRepository) WriteFile(userID, fileName string, content []byte) (string, error) {
-	directory := fmt.Sprintf("%s/%s/", constants.FILE_STORAGE_DIRECTORY, userID)
-	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
-		return "", err
+func (r *fileRepository) GetTrashed(fileID string) (entity.File, error) {
+	var file entity.File
+	if err := r.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", fileID).First(&file).Error; err != nil {
+		return entity.File{}, err
 	}
+	return file, nil
+}
 
-	filePath := fmt.Sprintf("%s%s", directory, fileName)
-	newFile, err := os.Create(filePath)
-	defer newFile.Close()
+// GetTrashPagination lists the files in the trash of a user, most recently
+// deleted first.
+func (r *fileRepository) GetTrashPagination(userID string, limit, page int) ([]entity.File, int64, int64, error) {
+	var files []entity.File
+	var count int64
+
+	query := r.db.Unscoped().Model(&entity.File{}).Where("user_id = ? AND deleted_at IS NOT NULL", userID)
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return nil, 0, 0, err
+	}
+
+	maxPage := int64(math.Ceil(float64(count) / float64(limit)))
+	offset := (page - 1) * limit
+
+	if err := query.Order("deleted_at DESC").Offset(offset).Limit(limit).Find(&files).Error; err != nil {
+		return nil, 0, 0, err
+	}
+
+	return files, maxPage, count, nil
+}
+
+// GetTrashedBefore returns the files of every user moved to the trash before
+// the given time.
+func (r *fileRepository) GetTrashedBefore(before time.Time) ([]entity.File, error) {
+	var files []entity.File
+	if err := r.db.Unscoped().Where("deleted_at < ?", before).Find(&files).Error; err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (r *fileRepository) Restore(fileID string) error {
+	return r.db.Unscoped().Model(&entity.File{}).Where("id = ?", fileID).Update("deleted_at", nil).Error
+}
+
+// WriteBlob takes a reference on the blob with the given checksum, writing
+// content to storage only if no file with the same content exists yet. It
+// returns the path files referencing the blob should store.
+func (r *fileRepository) WriteBlob(ctx context.Context, checksum string, content io.Reader, size int64) (string, error) {
+	blob := entity.Blob{
+		Checksum: checksum,
+		Path:     blobKey(checksum),
+		Size:     size,
+		RefCount: 1,
+	}
+
+	// the reference is taken before looking at storage, so a concurrent release
+	// of the last reference has either removed the object already or waits
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "checksum"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"ref_count": gorm.Expr("blobs.ref_count + 1"), "updated_at": time.Now()}),
+	}).Create(&blob).Error
 	if err != nil {
 		return "", err
 	}
 
-	_, err = newFile.Write(content)
+	_, err = r.storage.Stat(ctx, blob.Path)
+	if err == storage.ErrObjectNotFound {
+		err = r.storage.Put(ctx, blob.Path, content, size)
+	}
 	if err != nil {
+		r.ReleaseBlob(ctx, checksum)
 		return "", err
 	}
 
-	return filePath, nil
+	return blob.Path, nil
+}
+
+// ReleaseBlob drops a reference taken by WriteBlob that no file ended up
+// holding.
+func (r *fileRepository) ReleaseBlob(ctx context.Context, checksum string) error {
+	var released releasedContent
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return releaseBlob(tx, checksum, &released)
+	})
+	if err != nil {
+		return err
+	}
+
+	r.deleteReleased(ctx, released)
+	return nil
+}
+
+// releaseBlob drops a reference on a blob within tx. A blob no file references
+// anymore keeps its row with a zero count until deleteReleased removes it, so
+// a rollback leaves its content in place.
+func releaseBlob(tx *gorm.DB, checksum string, released *releasedContent) error {
+	var blob entity.Blob
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("checksum = ?", checksum).First(&blob).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	if err := tx.Model(&blob).Update("ref_count", gorm.Expr("ref_count - 1")).Error; err != nil {
+		return err
+	}
+	if blob.RefCount <= 1 {
+		released.blobs = append(released.blobs, checksum)
+	}
+	return nil
+}
+
+// releaseContent releases the content stored at path within tx. Blobs drop a
+// reference, content stored before deduplication is deleted after the commit.
+func releaseContent(tx *gorm.DB, path, checksum string, released *releasedContent) error {
+	if !strings.HasPrefix(path, constants.BLOB_STORAGE_PREFIX+"/") {
+		released.objects = append(released.objects, objectKey(path))
+		return nil
+	}
+	return releaseBlob(tx, checksum, released)
 }
 
-func (r *fileRepository) DeleteFile(file entity.File) error {
-	if _, err := os.Lstat(file.Path); err != nil {
-		if os.IsNotExist(err) {
-			return r.db.Where("id = ?", file.ID.String()).Delete(&entity.File{}).Error
+// deleteReleased deletes the content released by a committed transaction.
+// Failures are only logged, the storage checker removes what is left behind.
+func (r *fileRepository) deleteReleased(ctx context.Context, released releasedContent) {
+	for _, key := range released.objects {
+		if err := r.storage.Delete(ctx, key); err != nil && err != storage.ErrObjectNotFound {
+			log.Printf("error deleting object %s: %v", key, err)
 		}
 	}
+	for _, checksum := range released.blobs {
+		if err := r.removeBlob(ctx, checksum); err != nil {
+			log.Printf("error deleting blob %s: %v", checksum, err)
+		}
+	}
+}
 
-	if err := os.Remove(file.Path); err != nil {
+// removeBlob removes a blob whose last reference was released, unless an
+// upload took a new one since. The object is deleted while the row is locked,
+// so an upload of the same content waits and then writes it again instead of
+// reusing it.
+func (r *fileRepository) removeBlob(ctx context.Context, checksum string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var blob entity.Blob
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("checksum = ? AND ref_count <= 0", checksum).First(&blob).Error
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			return err
+		}
+
+		if err := tx.Delete(&blob).Error; err != nil {
+			return err
+		}
+		if err := deleteThumbnails(ctx, r.storage, checksum); err != nil {
+			return err
+		}
+		if err := r.storage.Delete(ctx, blob.Path); err != nil && err != storage.ErrObjectNotFound {
+			return err
+		}
+		return nil
+	})
+}
+
+// DeleteFile permanently removes the file row with its versions and releases
+// their content in one transaction, so a row never outlives its reference.
+// The content itself is deleted once the transaction committed.
+func (r *fileRepository) DeleteFile(ctx context.Context, file entity.File) error {
+	var released releasedContent
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var versions []entity.FileVersion
+		if err := tx.Where("file_id = ?", file.ID.String()).Find(&versions).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("file_id = ?", file.ID.String()).Delete(&entity.FileVersion{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("id = ?", file.ID.String()).Delete(&entity.File{}).Error; err != nil {
+			return err
+		}
+
+		for _, version := range versions {
+			if err := releaseContent(tx, version.Path, version.Checksum, &released); err != nil {
+				return err
+			}
+		}
+		return releaseContent(tx, file.Path, file.Checksum, &released)
+	})
+	if err != nil {
 		return err
 	}
 
-	return r.db.Where("id = ?", file.ID.String()).Delete(&entity.File{}).Error
+	r.deleteReleased(ctx, released)
+	return nil
+}
+
+// GetVersions lists the previous versions of a file, newest first.
+func (r *fileRepository) GetVersions(fileID string) ([]entity.FileVersion, error) {
+	var versions []entity.FileVersion
+	if err := r.db.Where("file_id = ?", fileID).Order("version DESC").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (r *fileRepository) GetVersion(fileID string, version int) (entity.FileVersion, error) {
+	var fileVersion entity.FileVersion
+	if err := r.db.Where("file_id = ? AND version = ?", fileID, version).First(&fileVersion).Error; err != nil {
+		return entity.FileVersion{}, err
+	}
+	return fileVersion, nil
+}
+
+// replaceContent archives the current content of the locked file as a
+// version and makes content the current one. The file's blob reference moves
+// to the version row and the one held by content to the file row.
+func replaceContent(tx *gorm.DB, file entity.File, content entity.FileVersion) (entity.File, error) {
+	modifiedAt := file.ModifiedAt
+	if modifiedAt.IsZero() {
+		modifiedAt = file.CreatedAt
+	}
+
+	if err := tx.Create(&entity.FileVersion{
+		FileID:     file.ID,
+		Version:    file.Version,
+		Path:       file.Path,
+		Size:       file.Size,
+		MimeType:   file.MimeType,
+		Checksum:   file.Checksum,
+		ModifiedAt: modifiedAt,
+	}).Error; err != nil {
+		return entity.File{}, err
+	}
+
+	file.Path = content.Path
+	file.Size = content.Size
+	file.MimeType = content.MimeType
+	file.Checksum = content.Checksum
+	file.Version++
+	file.ModifiedAt = time.Now()
+
+	err := tx.Model(&file).Select("path", "size", "mime_type", "checksum", "version", "modified_at").Updates(&file).Error
+	if err != nil {
+		return entity.File{}, err
+	}
+	return file, nil
+}
+
+func lockFile(tx *gorm.DB, fileID string) (entity.File, error) {
+	var file entity.File
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", fileID).First(&file).Error
+	return file, err
+}
+
+// ReplaceContent makes content, whose blob reference has been taken with
+// WriteBlob, the new version of the file if it fits in the quota of the file's
+// owner.
+func (r *fileRepository) ReplaceContent(fileID string, content entity.FileVersion, quota int64) (entity.File, error) {
+	var file entity.File
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		current, err := lockFile(tx, fileID)
+		if err != nil {
+			return err
+		}
+		if err := reserveQuota(tx, current.UserID.String(), content.Size, quota); err != nil {
+			return err
+		}
+
+		file, err = replaceContent(tx, current, content)
+		return err
+	})
+	return file, err
+}
+
+// RestoreVersion makes the content of a previous version current again as a
+// new version. The restored version row is removed, its blob reference now
+// being held by the file row.
+func (r *fileRepository) RestoreVersion(fileID string, version int) (entity.File, error) {
+	var file entity.File
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		current, err := lockFile(tx, fileID)
+		if err != nil {
+			return err
+		}
+
+		var restored entity.FileVersion
+		if err := tx.Where("file_id = ? AND version = ?", fileID, version).First(&restored).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&restored).Error; err != nil {
+			return err
+		}
+
+		file, err = replaceContent(tx, current, restored)
+		return err
+	})
+	return file, err
+}
+
+// PruneVersions removes all but the keep newest previous versions of a file.
+func (r *fileRepository) PruneVersions(ctx context.Context, fileID string, keep int) error {
+	var released releasedContent
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockFile(tx, fileID); err != nil {
+			return err
+		}
+
+		var versions []entity.FileVersion
+		if err := tx.Where("file_id = ?", fileID).Order("version DESC").Offset(keep).Find(&versions).Error; err != nil {
+			return err
+		}
+
+		for _, version := range versions {
+			if err := tx.Delete(&version).Error; err != nil {
+				return err
+			}
+			if err := releaseContent(tx, version.Path, version.Checksum, &released); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	r.deleteReleased(ctx, released)
+	return nil
 }
 
-func (r *fileRepository) ReadFile(file entity.File) ([]byte, error) {
-	content, err := os.ReadFile(file.Path)
+func (r *fileRepository) OpenFile(ctx context.Context, file entity.File) (storage.Object, error) {
+	object, err := storage.Open(ctx, r.storage, objectKey(file.Path))
 	if err != nil {
-		if os.IsNotExist(err) {
+		if err == storage.ErrObjectNotFound {
 			return nil, dto.ErrFileNotFound
 		}
 		return nil, err
 	}
 
-	return content, nil
+	return object, nil
 }
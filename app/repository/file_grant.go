@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"FP-DevOps/entity"
+	"math"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	FileGrantRepository interface {
+		Get(string) (entity.FileGrant, error)
+		GetPermission(string, string) (string, error)
+		GetByFile(string) ([]entity.FileGrant, error)
+		GetSharedWithPagination(string, int, int) ([]entity.FileGrant, int64, int64, error)
+		Upsert(entity.FileGrant) (entity.FileGrant, error)
+		Delete(string) error
+	}
+
+	fileGrantRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewFileGrantRepository(db *gorm.DB) FileGrantRepository {
+	return &fileGrantRepository{
+		db: db,
+	}
+}
+
+func (r *fileGrantRepository) Get(grantID string) (entity.FileGrant, error) {
+	var grant entity.FileGrant
+	if err := r.db.Preload("User").Where("id = ?", grantID).First(&grant).Error; err != nil {
+		return entity.FileGrant{}, err
+	}
+	return grant, nil
+}
+
+// GetPermission returns the permission the user was granted on the file, it
+// is empty when the file was not shared with them.
+func (r *fileGrantRepository) GetPermission(fileID, userID string) (string, error) {
+	var grant entity.FileGrant
+	err := r.db.Where("file_id = ? AND user_id = ?", fileID, userID).Take(&grant).Error
+	if err == gorm.ErrRecordNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return grant.Permission, nil
+}
+
+// GetByFile lists who the file is shared with, by username.
+func (r *fileGrantRepository) GetByFile(fileID string) ([]entity.FileGrant, error) {
+	var grants []entity.FileGrant
+	err := r.db.Joins("User").
+		Where("file_grants.file_id = ?", fileID).
+		Order(`"User"."username"`).
+		Find(&grants).Error
+	if err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+// GetSharedWithPagination lists the grants of a user with their files and
+// the files' owners, files in the trash are left out.
+func (r *fileGrantRepository) GetSharedWithPagination(userID string, limit, page int) ([]entity.FileGrant, int64, int64, error) {
+	var grants []entity.FileGrant
+	var count int64
+
+	query := r.db.Model(&entity.FileGrant{}).
+		Joins("JOIN files ON files.id = file_grants.file_id AND files.deleted_at IS NULL").
+		Where("file_grants.user_id = ?", userID)
+
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return nil, 0, 0, err
+	}
+
+	maxPage := int64(math.Ceil(float64(count) / float64(limit)))
+	offset := (page - 1) * limit
+
+	err := query.Preload("File.User").
+		Order("files.filename").Order("file_grants.id").
+		Offset(offset).Limit(limit).Find(&grants).Error
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return grants, maxPage, count, nil
+}
+
+// Upsert grants the permission, replacing the one the user had on the file.
+func (r *fileGrantRepository) Upsert(grant entity.FileGrant) (entity.FileGrant, error) {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "file_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"permission", "updated_at"}),
+	}).Create(&grant).Error
+	if err != nil {
+		return entity.FileGrant{}, err
+	}
+
+	if err := r.db.Where("file_id = ? AND user_id = ?", grant.FileID, grant.UserID).First(&grant).Error; err != nil {
+		return entity.FileGrant{}, err
+	}
+	return grant, nil
+}
+
+func (r *fileGrantRepository) Delete(grantID string) error {
+	return r.db.Unscoped().Where("id = ?", grantID).Delete(&entity.FileGrant{}).Error
+}
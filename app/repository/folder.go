@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"FP-DevOps/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	FolderRepository interface {
+		Get(string) (entity.Folder, error)
+		GetChildren(string, *uuid.UUID, *uuid.UUID) ([]entity.Folder, error)
+		GetAncestors(string) ([]entity.Folder, error)
+		GetDescendants(string) ([]entity.Folder, error)
+		Create(entity.Folder) (entity.Folder, error)
+		Rename(string, string) error
+		Move(string, *uuid.UUID) error
+		Delete([]string) error
+		DeleteTree(string) error
+	}
+
+	folderRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewFolderRepository(db *gorm.DB) FolderRepository {
+	return &folderRepository{
+		db: db,
+	}
+}
+
+func (r *folderRepository) Get(folderID string) (entity.Folder, error) {
+	var folder entity.Folder
+	if err := r.db.Where("id = ?", folderID).First(&folder).Error; err != nil {
+		return entity.Folder{}, err
+	}
+	return folder, nil
+}
+
+// GetChildren lists the folders directly inside parentID. When parentID is
+// nil it lists the root folders of the team, or of the user's own storage
+// without a team.
+func (r *folderRepository) GetChildren(userID string, teamID, parentID *uuid.UUID) ([]entity.Folder, error) {
+	query := r.db
+	if parentID != nil {
+		query = query.Where("parent_id = ?", *parentID)
+	} else if teamID != nil {
+		query = query.Where("parent_id IS NULL AND team_id = ?", *teamID)
+	} else {
+		query = query.Where("parent_id IS NULL AND user_id = ? AND team_id IS NULL", userID)
+	}
+
+	var folders []entity.Folder
+	if err := query.Order("name").Find(&folders).Error; err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+// GetAncestors returns the path from the root down to the folder, the folder
+// itself included.
+func (r *folderRepository) GetAncestors(folderID string) ([]entity.Folder, error) {
+	var folders []entity.Folder
+	err := r.db.Raw(`
+		WITH RECURSIVE ancestors AS (
+			SELECT folders.*, 0 AS depth FROM folders WHERE id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT folders.*, ancestors.depth + 1 FROM folders
+			JOIN ancestors ON folders.id = ancestors.parent_id
+			WHERE folders.deleted_at IS NULL
+		)
+		SELECT * FROM ancestors ORDER BY depth DESC`, folderID).Scan(&folders).Error
+	if err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+// descendantIDs returns the IDs of the folder and of every folder nested in
+// it.
+func descendantIDs(db *gorm.DB, folderID string) ([]string, error) {
+	var ids []string
+	err := db.Raw(`
+		WITH RECURSIVE descendants AS (
+			SELECT id FROM folders WHERE id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT folders.id FROM folders
+			JOIN descendants ON folders.parent_id = descendants.id
+			WHERE folders.deleted_at IS NULL
+		)
+		SELECT id FROM descendants`, folderID).Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetDescendants returns the folder itself followed by all of its subfolders.
+func (r *folderRepository) GetDescendants(folderID string) ([]entity.Folder, error) {
+	var folders []entity.Folder
+	err := r.db.Raw(`
+		WITH RECURSIVE descendants AS (
+			SELECT * FROM folders WHERE id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT folders.* FROM folders
+			JOIN descendants ON folders.parent_id = descendants.id
+			WHERE folders.deleted_at IS NULL
+		)
+		SELECT * FROM descendants`, folderID).Scan(&folders).Error
+	if err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+func (r *folderRepository) Create(folder entity.Folder) (entity.Folder, error) {
+	if err := r.db.Create(&folder).Error; err != nil {
+		return entity.Folder{}, err
+	}
+	return folder, nil
+}
+
+func (r *folderRepository) Rename(folderID, name string) error {
+	return r.db.Model(&entity.Folder{}).Where("id = ?", folderID).Update("name", name).Error
+}
+
+func (r *folderRepository) Move(folderID string, parentID *uuid.UUID) error {
+	return r.db.Model(&entity.Folder{}).Where("id = ?", folderID).Update("parent_id", parentID).Error
+}
+
+// Delete removes the folders for good, files still referencing them through
+// the trash end up at the root.
+func (r *folderRepository) Delete(folderIDs []string) error {
+	return r.db.Unscoped().Where("id IN ?", folderIDs).Delete(&entity.Folder{}).Error
+}
+
+// DeleteTree moves the files in a folder and its subfolders to the trash and
+// removes the folders, all in one transaction. The folders are locked first,
+// so files or subfolders created in them meanwhile wait and then fail instead
+// of ending up at the root.
+func (r *folderRepository) DeleteTree(folderID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var folderIDs []string
+		for {
+			ids, err := descendantIDs(tx, folderID)
+			if err != nil {
+				return err
+			}
+			// a subfolder created before its parent was locked is only seen
+			// by the next look
+			if len(ids) == len(folderIDs) {
+				break
+			}
+			folderIDs = ids
+
+			var locked []entity.Folder
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", folderIDs).Find(&locked).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("folder_id IN ?", folderIDs).Delete(&entity.File{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", folderIDs).Delete(&entity.Folder{}).Error
+	})
+}
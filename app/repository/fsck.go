@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"FP-DevOps/constants"
+	"FP-DevOps/entity"
+	"FP-DevOps/storage"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	// FsckRepository gives the consistency checker a view of everything that
+	// references stored content.
+	FsckRepository interface {
+		ListObjects(context.Context) ([]storage.ObjectInfo, error)
+		GetBlobs() ([]entity.Blob, error)
+		GetContentRefs() (map[string]int64, error)
+		GetUploadIDs() (map[string]bool, error)
+		SetBlobRefCount(string, int64, time.Time) (bool, error)
+		RemoveOrphanBlob(context.Context, string, time.Time, bool) (bool, error)
+		QuarantineObject(context.Context, string) error
+		DeleteObject(context.Context, string) error
+	}
+
+	fsckRepository struct {
+		db      *gorm.DB
+		storage storage.Backend
+	}
+)
+
+func NewFsckRepository(db *gorm.DB, storage storage.Backend) FsckRepository {
+	return &fsckRepository{
+		db:      db,
+		storage: storage,
+	}
+}
+
+func (r *fsckRepository) ListObjects(ctx context.Context) ([]storage.ObjectInfo, error) {
+	return r.storage.List(ctx, "")
+}
+
+func (r *fsckRepository) GetBlobs() ([]entity.Blob, error) {
+	var blobs []entity.Blob
+	if err := r.db.Find(&blobs).Error; err != nil {
+		return nil, err
+	}
+	return blobs, nil
+}
+
+// GetContentRefs counts the files, trashed ones included, and versions using
+// each storage key.
+func (r *fsckRepository) GetContentRefs() (map[string]int64, error) {
+	var rows []struct {
+		Path  string
+		Count int64
+	}
+	err := r.db.Raw(`
+		SELECT path, COUNT(*) AS count FROM (
+			SELECT path FROM files
+			UNION ALL
+			SELECT path FROM file_versions
+		) AS refs GROUP BY path`).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		refs[objectKey(row.Path)] += row.Count
+	}
+	return refs, nil
+}
+
+func (r *fsckRepository) GetUploadIDs() (map[string]bool, error) {
+	var ids []string
+	if err := r.db.Unscoped().Model(&entity.Upload{}).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+
+	uploads := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		uploads[id] = true
+	}
+	return uploads, nil
+}
+
+// SetBlobRefCount corrects the reference count of a blob unless it changed
+// since before, as the count it was compared with may be outdated then.
+func (r *fsckRepository) SetBlobRefCount(checksum string, refCount int64, before time.Time) (bool, error) {
+	result := r.db.Model(&entity.Blob{}).Where("checksum = ? AND updated_at < ?", checksum, before).Update("ref_count", refCount)
+	return result.RowsAffected > 0, result.Error
+}
+
+// RemoveOrphanBlob removes a blob nothing references, quarantining or
+// deleting its object, unless it changed since before. As in removeBlob the
+// row stays locked until the object is gone, so a concurrent upload of the
+// same content waits and writes it again.
+func (r *fsckRepository) RemoveOrphanBlob(ctx context.Context, checksum string, before time.Time, quarantine bool) (bool, error) {
+	removed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var blob entity.Blob
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("checksum = ? AND updated_at < ?", checksum, before).First(&blob).Error
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			return err
+		}
+
+		if err := tx.Delete(&blob).Error; err != nil {
+			return err
+		}
+
+		if quarantine {
+			err = r.QuarantineObject(ctx, blob.Path)
+		} else {
+			err = r.DeleteObject(ctx, blob.Path)
+		}
+		if err != nil && err != storage.ErrObjectNotFound {
+			return err
+		}
+
+		removed = true
+		return nil
+	})
+	return removed, err
+}
+
+// QuarantineObject moves an object under the quarantine prefix, where it is
+// kept for inspection instead of being deleted.
+func (r *fsckRepository) QuarantineObject(ctx context.Context, key string) error {
+	content, err := r.storage.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	if err := r.storage.Put(ctx, constants.QUARANTINE_STORAGE_PREFIX+"/"+key, content, -1); err != nil {
+		return err
+	}
+	return r.storage.Delete(ctx, key)
+}
+
+func (r *fsckRepository) DeleteObject(ctx context.Context, key string) error {
+	if err := r.storage.Delete(ctx, key); err != nil && err != storage.ErrObjectNotFound {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"FP-DevOps/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type (
+	ShareLinkRepository interface {
+		Get(string) (entity.ShareLink, error)
+		GetByToken(string) (entity.ShareLink, error)
+		GetByFile(string) ([]entity.ShareLink, error)
+		Create(entity.ShareLink) (entity.ShareLink, error)
+		Revoke(string) (entity.ShareLink, error)
+		CountDownload(string) (bool, error)
+	}
+
+	shareLinkRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewShareLinkRepository(db *gorm.DB) ShareLinkRepository {
+	return &shareLinkRepository{
+		db: db,
+	}
+}
+
+func (r *shareLinkRepository) Get(linkID string) (entity.ShareLink, error) {
+	var link entity.ShareLink
+	if err := r.db.Where("id = ?", linkID).First(&link).Error; err != nil {
+		return entity.ShareLink{}, err
+	}
+	return link, nil
+}
+
+func (r *shareLinkRepository) GetByToken(token string) (entity.ShareLink, error) {
+	var link entity.ShareLink
+	if err := r.db.Where("token = ?", token).First(&link).Error; err != nil {
+		return entity.ShareLink{}, err
+	}
+	return link, nil
+}
+
+// GetByFile lists the links to a file, newest first.
+func (r *shareLinkRepository) GetByFile(fileID string) ([]entity.ShareLink, error) {
+	links := []entity.ShareLink{}
+	if err := r.db.Where("file_id = ?", fileID).Order("created_at DESC").Find(&links).Error; err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (r *shareLinkRepository) Create(link entity.ShareLink) (entity.ShareLink, error) {
+	if err := r.db.Create(&link).Error; err != nil {
+		return entity.ShareLink{}, err
+	}
+	return link, nil
+}
+
+// Revoke cuts access through the link, revoking it again keeps the time it
+// was first revoked.
+func (r *shareLinkRepository) Revoke(linkID string) (entity.ShareLink, error) {
+	err := r.db.Model(&entity.ShareLink{}).
+		Where("id = ? AND revoked_at IS NULL", linkID).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return entity.ShareLink{}, err
+	}
+	return r.Get(linkID)
+}
+
+// CountDownload counts a download of the link, telling false when the link
+// has no downloads left. The limit is checked in the same statement, so
+// concurrent downloads cannot go past it.
+func (r *shareLinkRepository) CountDownload(linkID string) (bool, error) {
+	res := r.db.Model(&entity.ShareLink{}).
+		Where("id = ? AND (max_downloads IS NULL OR downloads < max_downloads)", linkID).
+		Update("downloads", gorm.Expr("downloads + 1"))
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
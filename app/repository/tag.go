@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"FP-DevOps/dto"
+	"FP-DevOps/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	TagRepository interface {
+		Get(string) (entity.Tag, error)
+		GetWithCounts(string) ([]dto.TagResponse, error)
+		GetFileTags(string, string) ([]entity.Tag, error)
+		FirstOrCreate(string, string) (entity.Tag, error)
+		AddToFile(entity.File, []entity.Tag) error
+		RemoveFromFile(entity.File, string) error
+		Delete(string) error
+	}
+
+	tagRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{
+		db: db,
+	}
+}
+
+func (r *tagRepository) Get(tagID string) (entity.Tag, error) {
+	var tag entity.Tag
+	if err := r.db.Where("id = ?", tagID).First(&tag).Error; err != nil {
+		return entity.Tag{}, err
+	}
+	return tag, nil
+}
+
+// GetWithCounts lists the tags of a user by name with the number of files
+// outside of the trash they are on.
+func (r *tagRepository) GetWithCounts(userID string) ([]dto.TagResponse, error) {
+	tags := []dto.TagResponse{}
+	err := r.db.Model(&entity.Tag{}).
+		Select("tags.id, tags.name, COUNT(files.id) AS count").
+		Joins("LEFT JOIN file_tags ON file_tags.tag_id = tags.id").
+		Joins("LEFT JOIN files ON files.id = file_tags.file_id AND files.deleted_at IS NULL").
+		Where("tags.user_id = ?", userID).
+		Group("tags.id, tags.name").
+		Order("tags.name").
+		Scan(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// GetFileTags lists the tags the user put on the file, files of a team are
+// tagged by each member on their own.
+func (r *tagRepository) GetFileTags(fileID, userID string) ([]entity.Tag, error) {
+	var tags []entity.Tag
+	err := r.db.Joins("JOIN file_tags ON file_tags.tag_id = tags.id").
+		Where("file_tags.file_id = ? AND tags.user_id = ?", fileID, userID).
+		Order("tags.name").
+		Find(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// FirstOrCreate returns the tag of the user with the given name, creating it
+// when the user has none yet. Concurrent requests creating the same tag end
+// up with the same row.
+func (r *tagRepository) FirstOrCreate(userID, name string) (entity.Tag, error) {
+	tag := entity.Tag{
+		Name:   name,
+		UserID: uuid.MustParse(userID),
+	}
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&tag).Error
+	if err != nil {
+		return entity.Tag{}, err
+	}
+
+	if err := r.db.Where("user_id = ? AND name = ?", userID, name).First(&tag).Error; err != nil {
+		return entity.Tag{}, err
+	}
+	return tag, nil
+}
+
+func (r *tagRepository) AddToFile(file entity.File, tags []entity.Tag) error {
+	return r.db.Model(&file).Association("Tags").Append(tags)
+}
+
+func (r *tagRepository) RemoveFromFile(file entity.File, tagID string) error {
+	return r.db.Exec("DELETE FROM file_tags WHERE file_id = ? AND tag_id = ?", file.ID, tagID).Error
+}
+
+// Delete removes the tag from every file before deleting it for good, so its
+// name can be used again.
+func (r *tagRepository) Delete(tagID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM file_tags WHERE tag_id = ?", tagID).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id = ?", tagID).Delete(&entity.Tag{}).Error
+	})
+}
@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	TeamRepository interface {
+		Get(string) (entity.Team, error)
+		GetByUser(string) ([]entity.TeamMember, error)
+		GetMembers(string) ([]entity.TeamMember, error)
+		GetRole(string, string) (string, error)
+		HasContent(string) (bool, error)
+		Create(entity.Team, entity.TeamMember) (entity.Team, error)
+		SetRole(string, string, string) error
+		RemoveMember(string, string) error
+		Delete(string) error
+		GetInvitation(string) (entity.TeamInvitation, error)
+		GetInvitationsByUser(string) ([]entity.TeamInvitation, error)
+		UpsertInvitation(entity.TeamInvitation) (entity.TeamInvitation, error)
+		AcceptInvitation(entity.TeamInvitation) error
+		DeleteInvitation(string) error
+	}
+
+	teamRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewTeamRepository(db *gorm.DB) TeamRepository {
+	return &teamRepository{
+		db: db,
+	}
+}
+
+func (r *teamRepository) Get(teamID string) (entity.Team, error) {
+	var team entity.Team
+	if err := r.db.Where("id = ?", teamID).First(&team).Error; err != nil {
+		return entity.Team{}, err
+	}
+	return team, nil
+}
+
+// GetByUser lists the memberships of a user with their teams, by team name.
+func (r *teamRepository) GetByUser(userID string) ([]entity.TeamMember, error) {
+	var members []entity.TeamMember
+	err := r.db.Joins("Team").
+		Where("team_members.user_id = ?", userID).
+		Order(`"Team"."name"`).
+		Find(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// GetMembers lists the members of a team, by username.
+func (r *teamRepository) GetMembers(teamID string) ([]entity.TeamMember, error) {
+	var members []entity.TeamMember
+	err := r.db.Joins("User").
+		Where("team_members.team_id = ?", teamID).
+		Order(`"User"."username"`).
+		Find(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// GetRole returns the role of the user in the team, it is empty when they
+// are not a member.
+func (r *teamRepository) GetRole(teamID, userID string) (string, error) {
+	var member entity.TeamMember
+	err := r.db.Where("team_id = ? AND user_id = ?", teamID, userID).Take(&member).Error
+	if err == gorm.ErrRecordNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// HasContent reports whether any file or folder belongs to the team, files
+// in the trash included.
+func (r *teamRepository) HasContent(teamID string) (bool, error) {
+	var files, folders int64
+	if err := r.db.Unscoped().Model(&entity.File{}).Where("team_id = ?", teamID).Limit(1).Count(&files).Error; err != nil {
+		return false, err
+	}
+	if err := r.db.Model(&entity.Folder{}).Where("team_id = ?", teamID).Limit(1).Count(&folders).Error; err != nil {
+		return false, err
+	}
+	return files > 0 || folders > 0, nil
+}
+
+// Create creates the team together with its first member.
+func (r *teamRepository) Create(team entity.Team, owner entity.TeamMember) (entity.Team, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&team).Error; err != nil {
+			return err
+		}
+		owner.TeamID = team.ID
+		return tx.Create(&owner).Error
+	})
+	if err != nil {
+		return entity.Team{}, err
+	}
+	return team, nil
+}
+
+// checkLastOwner fails when the member is the only owner of the team, as a
+// team always needs someone to manage it. The owners stay locked until tx
+// ends, so concurrent changes to them are checked one after the other.
+func checkLastOwner(tx *gorm.DB, teamID, userID string) error {
+	var owners []entity.TeamMember
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("team_id = ? AND role = ?", teamID, constants.ENUM_TEAM_ROLE_OWNER).
+		Find(&owners).Error
+	if err != nil {
+		return err
+	}
+
+	for _, owner := range owners {
+		if owner.UserID.String() == userID && len(owners) <= 1 {
+			return dto.ErrLastTeamOwner
+		}
+	}
+	return nil
+}
+
+// SetRole changes the role of a member, unless that leaves the team without
+// an owner.
+func (r *teamRepository) SetRole(teamID, userID, role string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if role != constants.ENUM_TEAM_ROLE_OWNER {
+			if err := checkLastOwner(tx, teamID, userID); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&entity.TeamMember{}).
+			Where("team_id = ? AND user_id = ?", teamID, userID).
+			Update("role", role).Error
+	})
+}
+
+// RemoveMember deletes the membership for good, so the user can be invited
+// again, unless that leaves the team without an owner.
+func (r *teamRepository) RemoveMember(teamID, userID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkLastOwner(tx, teamID, userID); err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("team_id = ? AND user_id = ?", teamID, userID).Delete(&entity.TeamMember{}).Error
+	})
+}
+
+// Delete removes the team with its members and pending invitations.
+func (r *teamRepository) Delete(teamID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ?", teamID).Delete(&entity.TeamInvitation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("team_id = ?", teamID).Delete(&entity.TeamMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id = ?", teamID).Delete(&entity.Team{}).Error
+	})
+}
+
+func (r *teamRepository) GetInvitation(invitationID string) (entity.TeamInvitation, error) {
+	var invitation entity.TeamInvitation
+	err := r.db.Preload("Team").Preload("User").Preload("InvitedBy").
+		Where("id = ?", invitationID).
+		First(&invitation).Error
+	if err != nil {
+		return entity.TeamInvitation{}, err
+	}
+	return invitation, nil
+}
+
+// GetInvitationsByUser lists the pending invitations of a user, newest
+// first.
+func (r *teamRepository) GetInvitationsByUser(userID string) ([]entity.TeamInvitation, error) {
+	var invitations []entity.TeamInvitation
+	err := r.db.Preload("Team").Preload("User").Preload("InvitedBy").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// UpsertInvitation invites the user to the team, inviting them again only
+// changes the role and who invited them.
+func (r *teamRepository) UpsertInvitation(invitation entity.TeamInvitation) (entity.TeamInvitation, error) {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "team_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "invited_by_id"}),
+	}).Create(&invitation).Error
+	if err != nil {
+		return entity.TeamInvitation{}, err
+	}
+
+	// on conflict the ID generated for the new row is not the one kept
+	if err := r.db.Where("team_id = ? AND user_id = ?", invitation.TeamID, invitation.UserID).First(&invitation).Error; err != nil {
+		return entity.TeamInvitation{}, err
+	}
+	return invitation, nil
+}
+
+// AcceptInvitation makes the invited user a member with the role of the
+// invitation, which is used up.
+func (r *teamRepository) AcceptInvitation(invitation entity.TeamInvitation) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		member := entity.TeamMember{
+			TeamID: invitation.TeamID,
+			UserID: invitation.UserID,
+			Role:   invitation.Role,
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&member).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", invitation.ID).Delete(&entity.TeamInvitation{}).Error
+	})
+}
+
+func (r *teamRepository) DeleteInvitation(invitationID string) error {
+	return r.db.Where("id = ?", invitationID).Delete(&entity.TeamInvitation{}).Error
+}
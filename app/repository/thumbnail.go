@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/storage"
+	"context"
+	"fmt"
+	"io"
+)
+
+type (
+	// ThumbnailRepository stores the thumbnails of a blob next to it, keyed by
+	// the blob's checksum so files sharing content share their thumbnails.
+	ThumbnailRepository interface {
+		Exists(context.Context, string, int, string) (bool, error)
+		Write(context.Context, string, int, string, io.Reader, int64) error
+		Open(context.Context, string, int, string) (storage.Object, error)
+	}
+
+	thumbnailRepository struct {
+		storage storage.Backend
+	}
+)
+
+func NewThumbnailRepository(storage storage.Backend) ThumbnailRepository {
+	return &thumbnailRepository{
+		storage: storage,
+	}
+}
+
+// thumbnailPrefix is where the thumbnails of the blob with the given checksum
+// are stored.
+func thumbnailPrefix(checksum string) string {
+	return fmt.Sprintf("%s/%s/%s/", constants.THUMBNAIL_STORAGE_PREFIX, checksum[:2], checksum)
+}
+
+func thumbnailKey(checksum string, size int, ext string) string {
+	return fmt.Sprintf("%s%d.%s", thumbnailPrefix(checksum), size, ext)
+}
+
+func (r *thumbnailRepository) Exists(ctx context.Context, checksum string, size int, ext string) (bool, error) {
+	if _, err := r.storage.Stat(ctx, thumbnailKey(checksum, size, ext)); err != nil {
+		if err == storage.ErrObjectNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *thumbnailRepository) Write(ctx context.Context, checksum string, size int, ext string, content io.Reader, length int64) error {
+	return r.storage.Put(ctx, thumbnailKey(checksum, size, ext), content, length)
+}
+
+func (r *thumbnailRepository) Open(ctx context.Context, checksum string, size int, ext string) (storage.Object, error) {
+	object, err := storage.Open(ctx, r.storage, thumbnailKey(checksum, size, ext))
+	if err != nil {
+		if err == storage.ErrObjectNotFound {
+			return nil, dto.ErrThumbnailNotFound
+		}
+		return nil, err
+	}
+	return object, nil
+}
+
+// deleteThumbnails removes every thumbnail of the blob with the given
+// checksum.
+func deleteThumbnails(ctx context.Context, backend storage.Backend, checksum string) error {
+	thumbnails, err := backend.List(ctx, thumbnailPrefix(checksum))
+	if err != nil {
+		return err
+	}
+
+	for _, thumbnail := range thumbnails {
+		if err := backend.Delete(ctx, thumbnail.Key); err != nil && err != storage.ErrObjectNotFound {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/entity"
+	"FP-DevOps/storage"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type (
+	UploadRepository interface {
+		Get(string) (entity.Upload, error)
+		GetExpired(time.Time) ([]entity.Upload, error)
+		Create(entity.Upload) (entity.Upload, error)
+		Update(entity.Upload) (entity.Upload, error)
+		Delete(string) error
+		HasFile(string) (bool, error)
+		WriteChunk(context.Context, string, int64, io.Reader, int64) (string, error)
+		AppendChunk(context.Context, string, int64, io.Reader, int64, time.Time) (entity.Upload, error)
+		ReadChunks(context.Context, string) (io.ReadCloser, error)
+		DeleteChunks(context.Context, string) error
+	}
+
+	uploadRepository struct {
+		db      *gorm.DB
+		storage storage.Backend
+	}
+)
+
+func NewUploadRepository(db *gorm.DB, storage storage.Backend) UploadRepository {
+	return &uploadRepository{
+		db:      db,
+		storage: storage,
+	}
+}
+
+// chunkPrefix is where the chunks of an upload are stored. Chunk keys continue
+// with their zero padded offset and size, so they sort in upload order, and end
+// with a random suffix so two requests for the same offset never share a key.
+func chunkPrefix(uploadID string) string {
+	return fmt.Sprintf("%s/%s/", constants.UPLOAD_STORAGE_PREFIX, uploadID)
+}
+
+func chunkKey(uploadID string, offset, size int64) string {
+	return fmt.Sprintf("%s%020d-%020d-%s", chunkPrefix(uploadID), offset, size, uuid.NewString())
+}
+
+// parseChunkKey returns the offset and size of a chunk, the size is -1 for
+// chunks stored before it was part of the key.
+func parseChunkKey(key string) (int64, int64, error) {
+	parts := strings.SplitN(key[strings.LastIndex(key, "/")+1:], "-", 3)
+	offset, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(parts) == 1 {
+		return offset, -1, nil
+	}
+
+	size, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return offset, size, nil
+}
+
+func (r *uploadRepository) Get(uploadID string) (entity.Upload, error) {
+	var upload entity.Upload
+	if err := r.db.Where("id = ?", uploadID).First(&upload).Error; err != nil {
+		return entity.Upload{}, err
+	}
+
+	return upload, nil
+}
+
+func (r *uploadRepository) GetExpired(now time.Time) ([]entity.Upload, error) {
+	var uploads []entity.Upload
+	if err := r.db.Where("expires_at < ?", now).Find(&uploads).Error; err != nil {
+		return nil, err
+	}
+
+	return uploads, nil
+}
+
+func (r *uploadRepository) Create(upload entity.Upload) (entity.Upload, error) {
+	if err := r.db.Create(&upload).Error; err != nil {
+		return entity.Upload{}, err
+	}
+
+	return upload, nil
+}
+
+func (r *uploadRepository) Update(upload entity.Upload) (entity.Upload, error) {
+	if err := r.db.Updates(&upload).Error; err != nil {
+		return entity.Upload{}, err
+	}
+	return upload, nil
+}
+
+func (r *uploadRepository) Delete(uploadID string) error {
+	return r.db.Unscoped().Where("id = ?", uploadID).Delete(&entity.Upload{}).Error
+}
+
+// WriteChunk stores a chunk at offset under a key of its own and returns it.
+func (r *uploadRepository) WriteChunk(ctx context.Context, uploadID string, offset int64, content io.Reader, size int64) (string, error) {
+	key := chunkKey(uploadID, offset, size)
+	if err := r.storage.Put(ctx, key, content, size); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// AppendChunk stores a chunk at offset and then advances the upload past it,
+// only if it is still at offset. No lock is held while the chunk is written,
+// of two requests for the same offset the later one fails with
+// ErrUploadOffsetMismatch and deletes its chunk again.
+func (r *uploadRepository) AppendChunk(ctx context.Context, uploadID string, offset int64, content io.Reader, size int64, expiresAt time.Time) (entity.Upload, error) {
+	key, err := r.WriteChunk(ctx, uploadID, offset, content, size)
+	if err != nil {
+		return entity.Upload{}, err
+	}
+
+	result := r.db.Model(&entity.Upload{}).Where("id = ? AND \"offset\" = ?", uploadID, offset).Updates(map[string]interface{}{
+		"offset":     offset + size,
+		"expires_at": expiresAt,
+	})
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = dto.ErrUploadOffsetMismatch
+	}
+	if result.Error != nil {
+		r.storage.Delete(ctx, key)
+		return entity.Upload{}, result.Error
+	}
+
+	return r.Get(uploadID)
+}
+
+func (r *uploadRepository) HasFile(fileID string) (bool, error) {
+	var count int64
+	if err := r.db.Unscoped().Model(&entity.File{}).Where("id = ?", fileID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *uploadRepository) ReadChunks(ctx context.Context, uploadID string) (io.ReadCloser, error) {
+	chunks, err := r.storage.List(ctx, chunkPrefix(uploadID))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(chunks, func(i, j int) bool {
+		return chunks[i].Key < chunks[j].Key
+	})
+
+	return &chunkReader{ctx: ctx, storage: r.storage, chunks: chunks}, nil
+}
+
+func (r *uploadRepository) DeleteChunks(ctx context.Context, uploadID string) error {
+	chunks, err := r.storage.List(ctx, chunkPrefix(uploadID))
+	if err != nil {
+		return err
+	}
+
+	for _, chunk := range chunks {
+		if err := r.storage.Delete(ctx, chunk.Key); err != nil && err != storage.ErrObjectNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunkReader reads the chunks of an upload back to back, opening each one
+// only once the previous chunk is exhausted. A request that lost the race for
+// an offset may have left its chunk behind, it holds the same bytes of the
+// file, so chunks overlapping what was read already are skipped past.
+type chunkReader struct {
+	ctx      context.Context
+	storage  storage.Backend
+	chunks   []storage.ObjectInfo
+	position int64
+	current  io.ReadCloser
+}
+
+// next opens the next chunk continuing at position, or returns io.EOF once no
+// chunk is left.
+func (r *chunkReader) next() error {
+	for len(r.chunks) > 0 {
+		chunk := r.chunks[0]
+		r.chunks = r.chunks[1:]
+
+		offset, size, err := parseChunkKey(chunk.Key)
+		if err != nil {
+			return err
+		}
+		if size < 0 {
+			size = chunk.Size
+		}
+		if offset > r.position {
+			return io.ErrUnexpectedEOF
+		}
+		if offset+size <= r.position {
+			continue
+		}
+
+		current, err := r.storage.Get(r.ctx, chunk.Key)
+		if err != nil {
+			return err
+		}
+		if _, err := io.CopyN(io.Discard, current, r.position-offset); err != nil {
+			current.Close()
+			return err
+		}
+		r.current = current
+		return nil
+	}
+	return io.EOF
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if err := r.next(); err != nil {
+				return 0, err
+			}
+		}
+
+		n, err := r.current.Read(p)
+		r.position += int64(n)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *chunkReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
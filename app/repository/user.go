@@ -1,9 +1,11 @@
 package repository
 
 import (
+	"FP-DevOps/dto"
 	"FP-DevOps/entity"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type (
@@ -11,6 +13,7 @@ type (
 		Create(entity.User) (entity.User, error)
 		GetUserById(string) (entity.User, error)
 		GetUserByUsername(string) (entity.User, error)
+		GetStorageUsage(string) (int64, error)
 	}
 
 	userRepository struct {
@@ -47,3 +50,44 @@ func (r *userRepository) GetUserByUsername(username string) (entity.User, error)
 	}
 	return user, nil
 }
+
+// GetStorageUsage sums the size of the user's files and of their previous
+// versions. Files in the trash still take up storage and are counted.
+func (r *userRepository) GetStorageUsage(userID string) (int64, error) {
+	return storageUsed(r.db, userID)
+}
+
+func storageUsed(db *gorm.DB, userID string) (int64, error) {
+	var files, versions int64
+	if err := db.Unscoped().Model(&entity.File{}).Where("user_id = ?", userID).Select("COALESCE(SUM(size), 0)").Scan(&files).Error; err != nil {
+		return 0, err
+	}
+
+	err := db.Model(&entity.FileVersion{}).
+		Joins("JOIN files ON files.id = file_versions.file_id").
+		Where("files.user_id = ?", userID).
+		Select("COALESCE(SUM(file_versions.size), 0)").Scan(&versions).Error
+	if err != nil {
+		return 0, err
+	}
+	return files + versions, nil
+}
+
+// reserveQuota checks that size more bytes fit in the user's quota. The user
+// row stays locked until tx ends, so concurrent uploads by the same user are
+// checked one after the other against what the previous ones stored.
+func reserveQuota(tx *gorm.DB, userID string, size, quota int64) error {
+	var user entity.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userID).First(&user).Error; err != nil {
+		return err
+	}
+
+	used, err := storageUsed(tx, userID)
+	if err != nil {
+		return err
+	}
+	if used+size > quota {
+		return dto.ErrStorageQuotaExceeded
+	}
+	return nil
+}
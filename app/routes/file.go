@@ -8,13 +8,31 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func File(route *gin.Engine, fileController controller.FileController, jwtService config.JWTService) {
+func File(route *gin.Engine, fileController controller.FileController, uploadController controller.UploadController, jwtService config.JWTService) {
 	routes := route.Group("/api/file")
 	{
+		routes.OPTIONS("/upload", uploadController.Options)
+		routes.POST("/upload", middleware.Authenticate(jwtService), uploadController.Create)
+		routes.HEAD("/upload/:id", middleware.Authenticate(jwtService), uploadController.GetOffset)
+		routes.PATCH("/upload/:id", middleware.Authenticate(jwtService), uploadController.Append)
+
+		routes.POST("/extract", middleware.Authenticate(jwtService), fileController.Extract)
+		routes.POST("/archive", middleware.AuthenticateIfExists(jwtService), fileController.CreateArchive)
 		routes.GET("/:id", middleware.AuthenticateIfExists(jwtService), fileController.GetFileByID)
+		routes.HEAD("/:id", middleware.AuthenticateIfExists(jwtService), fileController.GetFileByID)
+		routes.POST("/:id/signed-url", middleware.Authenticate(jwtService), fileController.SignURL)
 		routes.GET("", middleware.Authenticate(jwtService), fileController.GetPaginated)
 		routes.POST("", middleware.Authenticate(jwtService), fileController.Create)
 		routes.PATCH("/:id", middleware.Authenticate(jwtService), fileController.UpdateByID)
+		routes.PATCH("/:id/move", middleware.Authenticate(jwtService), fileController.MoveByID)
+		routes.PUT("/:id", middleware.Authenticate(jwtService), fileController.CreateVersion)
+		routes.GET("/:id/thumbnail", middleware.AuthenticateIfExists(jwtService), fileController.GetThumbnailByID)
+		routes.GET("/:id/preview", middleware.AuthenticateIfExists(jwtService), fileController.GetPreviewByID)
+		routes.GET("/:id/versions", middleware.Authenticate(jwtService), fileController.GetVersions)
+		routes.GET("/:id/versions/:version", middleware.Authenticate(jwtService), fileController.GetVersion)
+		routes.POST("/:id/versions/:version/restore", middleware.Authenticate(jwtService), fileController.RestoreVersion)
 		routes.DELETE("/:id", middleware.Authenticate(jwtService), fileController.DeleteByID)
 	}
+
+	route.GET("/share/:id", middleware.AuthenticateIfExists(jwtService), fileController.GetSharePage)
 }
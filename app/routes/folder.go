@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/controller"
+	"FP-DevOps/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Folder(route *gin.Engine, folderController controller.FolderController, jwtService config.JWTService) {
+	routes := route.Group("/api/folder", middleware.Authenticate(jwtService))
+	{
+		routes.GET("", folderController.GetFolder)
+		routes.GET("/:id", folderController.GetFolder)
+		routes.POST("", folderController.Create)
+		routes.PATCH("/:id", folderController.RenameByID)
+		routes.PATCH("/:id/move", folderController.MoveByID)
+		routes.DELETE("/:id", folderController.DeleteByID)
+	}
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/controller"
+	"FP-DevOps/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Grant(route *gin.Engine, grantController controller.GrantController, jwtService config.JWTService) {
+	route.GET("/api/file/shared", middleware.Authenticate(jwtService), grantController.GetSharedWithMe)
+
+	files := route.Group("/api/file/:id/grants", middleware.Authenticate(jwtService))
+	{
+		files.GET("", grantController.GetGrants)
+		files.POST("", grantController.Grant)
+		files.DELETE("/:grant", grantController.RevokeByID)
+	}
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/controller"
+	"FP-DevOps/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Share(route *gin.Engine, shareController controller.ShareController, jwtService config.JWTService) {
+	routes := route.Group("/api/share", middleware.Authenticate(jwtService))
+	{
+		routes.DELETE("/:id", shareController.RevokeByID)
+	}
+
+	files := route.Group("/api/file/:id/shares", middleware.Authenticate(jwtService))
+	{
+		files.GET("", shareController.GetByFile)
+		files.POST("", shareController.Create)
+	}
+
+	public := route.Group("/s")
+	{
+		public.GET("/:token", shareController.Open)
+		public.HEAD("/:token", shareController.Open)
+		public.POST("/:token", shareController.Open)
+	}
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/controller"
+	"FP-DevOps/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Tag(route *gin.Engine, tagController controller.TagController, jwtService config.JWTService) {
+	routes := route.Group("/api/tag", middleware.Authenticate(jwtService))
+	{
+		routes.GET("", tagController.GetTags)
+		routes.DELETE("/:id", tagController.DeleteByID)
+	}
+
+	files := route.Group("/api/file/:id/tags", middleware.Authenticate(jwtService))
+	{
+		files.POST("", tagController.AddToFile)
+		files.DELETE("/:tag", tagController.RemoveFromFile)
+	}
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/controller"
+	"FP-DevOps/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Team(route *gin.Engine, teamController controller.TeamController, fileController controller.FileController, folderController controller.FolderController, jwtService config.JWTService) {
+	routes := route.Group("/api/team", middleware.Authenticate(jwtService))
+	{
+		routes.GET("", teamController.GetTeams)
+		routes.POST("", teamController.Create)
+		routes.GET("/invitations", teamController.GetInvitations)
+		routes.POST("/invitations/:id/accept", teamController.AcceptInvitation)
+		routes.DELETE("/invitations/:id", teamController.DeclineInvitation)
+		routes.GET("/:id", teamController.GetTeam)
+		routes.DELETE("/:id", teamController.DeleteByID)
+		routes.POST("/:id/invitations", teamController.Invite)
+		routes.PATCH("/:id/members/:user", teamController.UpdateMember)
+		routes.DELETE("/:id/members/:user", teamController.RemoveMember)
+
+		// the files and folders of a team are listed and uploaded through
+		// the same handlers as the user's own
+		routes.GET("/:id/files", fileController.GetTeamFiles)
+		routes.POST("/:id/files", fileController.CreateTeamFiles)
+		routes.GET("/:id/folders", folderController.GetTeamRoot)
+	}
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/controller"
+	"FP-DevOps/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Trash(route *gin.Engine, fileController controller.FileController, jwtService config.JWTService) {
+	routes := route.Group("/api/trash", middleware.Authenticate(jwtService))
+	{
+		routes.GET("", fileController.GetTrash)
+		routes.POST("/:id/restore", fileController.RestoreByID)
+		routes.DELETE("/:id", fileController.DeletePermanentlyByID)
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/entity"
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// archiveNames hands out unique paths inside an archive, numbering files that
+// would otherwise overwrite each other.
+type archiveNames map[string]bool
+
+func (n archiveNames) unique(name string) string {
+	if !n[name] {
+		n[name] = true
+		return name
+	}
+
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		if !n[candidate] {
+			n[candidate] = true
+			return candidate
+		}
+	}
+}
+
+// archiveSegment keeps a file or folder name from adding directories to the
+// path it is put at.
+func archiveSegment(name string) string {
+	return strings.ReplaceAll(name, "/", "_")
+}
+
+// folderPaths maps each of the folders to its path, starting at the first
+// folder which is the one the others are nested in.
+func folderPaths(folders []entity.Folder) map[uuid.UUID]string {
+	byID := make(map[uuid.UUID]entity.Folder, len(folders))
+	for _, folder := range folders {
+		byID[folder.ID] = folder
+	}
+
+	paths := make(map[uuid.UUID]string, len(folders))
+	var resolve func(folder entity.Folder) string
+	resolve = func(folder entity.Folder) string {
+		if p, ok := paths[folder.ID]; ok {
+			return p
+		}
+
+		p := archiveSegment(folder.Name)
+		if folder.ID != folders[0].ID && folder.ParentID != nil {
+			if parent, ok := byID[*folder.ParentID]; ok {
+				p = resolve(parent) + "/" + p
+			}
+		}
+		paths[folder.ID] = p
+		return p
+	}
+
+	for _, folder := range folders {
+		resolve(folder)
+	}
+	return paths
+}
+
+// GetArchive checks the files and folders to archive and lists the files the
+// archive is made of. Files are checked like downloading them, folders must
+// belong to the user.
+func (s *fileService) GetArchive(ctx context.Context, userID string, req dto.CreateArchiveRequest) (dto.ArchiveResponse, error) {
+	names := archiveNames{}
+	res := dto.ArchiveResponse{Filename: constants.DEFAULT_ARCHIVE_FILENAME}
+
+	for _, fileID := range req.FileIDs {
+		if _, err := uuid.Parse(fileID); err != nil {
+			return dto.ArchiveResponse{}, dto.ErrFileNotFound
+		}
+
+		file, err := s.GetFile(ctx, userID, fileID)
+		if err != nil {
+			return dto.ArchiveResponse{}, err
+		}
+		file.Content.Close()
+
+		res.Entries = append(res.Entries, dto.ArchiveEntry{
+			FileID: file.ID,
+			Name:   names.unique(archiveSegment(file.Filename)),
+		})
+	}
+
+	for _, folderID := range req.FolderIDs {
+		if _, err := getFolder(s.auth, s.folderRepo, userID, folderID, constants.ENUM_PERMISSION_VIEW); err != nil {
+			return dto.ArchiveResponse{}, err
+		}
+
+		folders, err := s.folderRepo.GetDescendants(folderID)
+		if err != nil {
+			return dto.ArchiveResponse{}, err
+		}
+		paths := folderPaths(folders)
+
+		ids := make([]string, 0, len(folders))
+		for _, folder := range folders {
+			ids = append(ids, folder.ID.String())
+		}
+		files, err := s.fileRepo.GetByFolders(ids)
+		if err != nil {
+			return dto.ArchiveResponse{}, err
+		}
+
+		for _, file := range files {
+			res.Entries = append(res.Entries, dto.ArchiveEntry{
+				FileID: file.ID.String(),
+				Name:   names.unique(paths[*file.FolderID] + "/" + archiveSegment(file.Filename)),
+			})
+		}
+
+		if len(req.FileIDs) == 0 && len(req.FolderIDs) == 1 {
+			res.Filename = archiveSegment(folders[0].Name) + ".zip"
+		}
+	}
+
+	if len(res.Entries) == 0 {
+		return dto.ArchiveResponse{}, dto.ErrArchiveEmpty
+	}
+	if len(res.Entries) > constants.MAX_ARCHIVE_FILES {
+		return dto.ArchiveResponse{}, dto.ErrArchiveTooLarge
+	}
+	return res, nil
+}
+
+// WriteArchive streams the files of the archive to w as a ZIP. Each file is
+// checked again and read from storage only while it is being written, so
+// nothing but the file being compressed is held in memory.
+func (s *fileService) WriteArchive(ctx context.Context, userID string, archive dto.ArchiveResponse, w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	for _, entry := range archive.Entries {
+		if err := s.writeArchiveEntry(ctx, userID, zw, entry); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (s *fileService) writeArchiveEntry(ctx context.Context, userID string, zw *zip.Writer, entry dto.ArchiveEntry) error {
+	file, err := s.GetFile(ctx, userID, entry.FileID)
+	if err != nil {
+		return err
+	}
+	defer file.Content.Close()
+
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     entry.Name,
+		Method:   zip.Deflate,
+		Modified: file.ModTime,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, file.Content)
+	return err
+}
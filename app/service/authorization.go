@@ -0,0 +1,164 @@
+package service
+
+import (
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/entity"
+	"FP-DevOps/repository"
+
+	"github.com/google/uuid"
+)
+
+type (
+	// Authorizer decides what users may do with files, folders and teams. A
+	// user's permission on a file comes from owning it, from their role in
+	// the team it belongs to and from the grants on it, whichever allows the
+	// most. Folders are not shared, only their owner or the members of their
+	// team see them.
+	Authorizer interface {
+		FilePermission(entity.File, string) (string, error)
+		CheckFile(entity.File, string, string) error
+		CheckFolder(entity.Folder, string, string) error
+		CheckTeam(string, string, string) (string, error)
+	}
+
+	authorizer struct {
+		grantRepo repository.FileGrantRepository
+		teamRepo  repository.TeamRepository
+	}
+)
+
+// permissionLevels orders the permissions, each includes the lower ones.
+var permissionLevels = map[string]int{
+	constants.ENUM_PERMISSION_VIEW:   1,
+	constants.ENUM_PERMISSION_EDIT:   2,
+	constants.ENUM_PERMISSION_MANAGE: 3,
+}
+
+// teamRoleLevels orders the team roles, each may do what the lower ones may.
+var teamRoleLevels = map[string]int{
+	constants.ENUM_TEAM_ROLE_VIEWER: 1,
+	constants.ENUM_TEAM_ROLE_MEMBER: 2,
+	constants.ENUM_TEAM_ROLE_ADMIN:  3,
+	constants.ENUM_TEAM_ROLE_OWNER:  4,
+}
+
+// teamRolePermissions is the permission each role has on the files and
+// folders of the team.
+var teamRolePermissions = map[string]string{
+	constants.ENUM_TEAM_ROLE_VIEWER: constants.ENUM_PERMISSION_VIEW,
+	constants.ENUM_TEAM_ROLE_MEMBER: constants.ENUM_PERMISSION_EDIT,
+	constants.ENUM_TEAM_ROLE_ADMIN:  constants.ENUM_PERMISSION_MANAGE,
+	constants.ENUM_TEAM_ROLE_OWNER:  constants.ENUM_PERMISSION_MANAGE,
+}
+
+func NewAuthorizer(grantRepo repository.FileGrantRepository, teamRepo repository.TeamRepository) Authorizer {
+	return &authorizer{
+		grantRepo: grantRepo,
+		teamRepo:  teamRepo,
+	}
+}
+
+// teamPermission is the permission the user's role gives them on what
+// belongs to the team, it is empty without a team or membership.
+func (a *authorizer) teamPermission(teamID *uuid.UUID, userID string) (string, error) {
+	if teamID == nil || userID == "" {
+		return "", nil
+	}
+
+	role, err := a.teamRepo.GetRole(teamID.String(), userID)
+	if err != nil {
+		return "", err
+	}
+	return teamRolePermissions[role], nil
+}
+
+// FilePermission is what the user may do with the file, it is empty when
+// they may not even view it.
+func (a *authorizer) FilePermission(file entity.File, userID string) (string, error) {
+	if userID == "" {
+		return "", nil
+	}
+	if file.TeamID == nil && file.UserID.String() == userID {
+		return constants.ENUM_PERMISSION_MANAGE, nil
+	}
+
+	permission, err := a.teamPermission(file.TeamID, userID)
+	if err != nil {
+		return "", err
+	}
+	if permission == constants.ENUM_PERMISSION_MANAGE {
+		return permission, nil
+	}
+
+	granted, err := a.grantRepo.GetPermission(file.ID.String(), userID)
+	if err != nil {
+		return "", err
+	}
+	if permissionLevels[granted] > permissionLevels[permission] {
+		return granted, nil
+	}
+	return permission, nil
+}
+
+// CheckFile fails unless the user has at least the permission on the file.
+func (a *authorizer) CheckFile(file entity.File, userID, permission string) error {
+	granted, err := a.FilePermission(file, userID)
+	if err != nil {
+		return err
+	}
+	if granted == "" || permissionLevels[granted] < permissionLevels[permission] {
+		return dto.ErrUnauthorizedFileAccess
+	}
+	return nil
+}
+
+// CheckFolder fails unless the user has at least the permission on the
+// folder.
+func (a *authorizer) CheckFolder(folder entity.Folder, userID, permission string) error {
+	if folder.TeamID == nil {
+		if folder.UserID.String() != userID {
+			return dto.ErrUnauthorizedFolderAccess
+		}
+		return nil
+	}
+
+	granted, err := a.teamPermission(folder.TeamID, userID)
+	if err != nil {
+		return err
+	}
+	if granted == "" || permissionLevels[granted] < permissionLevels[permission] {
+		return dto.ErrUnauthorizedFolderAccess
+	}
+	return nil
+}
+
+// CheckTeam fails unless the user has at least the role in the team and
+// returns the role they have. Teams the user is not a member of are not
+// found, so their existence is not given away.
+func (a *authorizer) CheckTeam(teamID, userID, role string) (string, error) {
+	if _, err := uuid.Parse(teamID); err != nil {
+		return "", dto.ErrTeamNotFound
+	}
+
+	current, err := a.teamRepo.GetRole(teamID, userID)
+	if err != nil {
+		return "", err
+	}
+	if current == "" {
+		return "", dto.ErrTeamNotFound
+	}
+	if teamRoleLevels[current] < teamRoleLevels[role] {
+		return current, dto.ErrUnauthorizedTeamAccess
+	}
+	return current, nil
+}
+
+// sameTeam reports whether two files or folders are in the same space, nil
+// being the user's own storage.
+func sameTeam(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/entity"
+	"FP-DevOps/utils"
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// extractBudget is the number of bytes an archive may still expand to. It is
+// counted on what is actually decompressed, not on the sizes the archive
+// claims.
+type extractBudget struct {
+	remaining int64
+}
+
+type budgetReader struct {
+	reader io.Reader
+	budget *extractBudget
+}
+
+func (r *budgetReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.budget.remaining -= int64(n)
+	if r.budget.remaining < 0 {
+		return n, dto.ErrArchiveExtractLimit
+	}
+	return n, err
+}
+
+// archiveError reports content that cannot be decompressed as a corrupted
+// archive rather than a server error.
+func archiveError(err error) error {
+	switch err {
+	case zip.ErrChecksum, zip.ErrFormat, zip.ErrAlgorithm, gzip.ErrChecksum, gzip.ErrHeader, tar.ErrHeader, io.ErrUnexpectedEOF:
+		return dto.ErrInvalidArchive
+	}
+	return err
+}
+
+// archiveWalker calls fn with the name and content of each regular file in
+// an archive.
+type archiveWalker func(archive *os.File, size int64, budget *extractBudget, fn func(string, io.Reader) error) error
+
+func walkZip(archive *os.File, size int64, budget *extractBudget, fn func(string, io.Reader) error) error {
+	reader, err := zip.NewReader(archive, size)
+	if err != nil {
+		return dto.ErrInvalidArchive
+	}
+
+	for _, entry := range reader.File {
+		if !entry.Mode().IsRegular() {
+			continue
+		}
+
+		content, err := entry.Open()
+		if err != nil {
+			return dto.ErrInvalidArchive
+		}
+		err = fn(entry.Name, &budgetReader{reader: content, budget: budget})
+		content.Close()
+		if err != nil {
+			return archiveError(err)
+		}
+	}
+	return nil
+}
+
+func walkTarGz(archive *os.File, size int64, budget *extractBudget, fn func(string, io.Reader) error) error {
+	gz, err := gzip.NewReader(archive)
+	if err != nil {
+		return dto.ErrInvalidArchive
+	}
+	defer gz.Close()
+
+	// the whole stream counts, entries that are skipped are decompressed too
+	reader := tar.NewReader(&budgetReader{reader: gz, budget: budget})
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err == dto.ErrArchiveExtractLimit {
+			return err
+		}
+		if err != nil {
+			return dto.ErrInvalidArchive
+		}
+
+		if !header.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		if err := fn(header.Name, reader); err != nil {
+			return archiveError(err)
+		}
+	}
+}
+
+func archiveFormat(filename string) (archiveWalker, error) {
+	filename = strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(filename, ".zip"):
+		return walkZip, nil
+	case strings.HasSuffix(filename, ".tar.gz"), strings.HasSuffix(filename, ".tgz"):
+		return walkTarGz, nil
+	}
+	return nil, dto.ErrUnsupportedArchive
+}
+
+// archiveEntryPath splits the name of an archive entry into sanitized path
+// segments. Names that are absolute or climb out of the archive with ".."
+// are rejected instead of being cleaned up.
+func archiveEntryPath(name string) ([]string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") {
+		return nil, dto.ErrUnsafeArchivePath
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(name, "/") {
+		switch segment {
+		case "", ".":
+			continue
+		case "..":
+			return nil, dto.ErrUnsafeArchivePath
+		}
+
+		if segment = utils.SanitizeFilename(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments, nil
+}
+
+// extraction keeps track of what extracting an archive created, so it can be
+// removed again when the archive turns out to be unusable.
+type extraction struct {
+	service *fileService
+	userID  string
+	// teamID is the team the archive is extracted for, nil for the user's
+	// own storage
+	teamID *uuid.UUID
+	// folders maps directories of the archive to the folder they went to
+	folders        map[string]*uuid.UUID
+	createdFolders []string
+	files          []dto.FileResponse
+	entries        int
+}
+
+// folder returns the folder for the directory made of segments, reusing
+// folders with the same name and creating the missing ones.
+func (e *extraction) folder(segments []string) (*uuid.UUID, error) {
+	parent := e.folders[""]
+	for i := range segments {
+		key := strings.Join(segments[:i+1], "/")
+		if folderID, ok := e.folders[key]; ok {
+			parent = folderID
+			continue
+		}
+
+		children, err := e.service.folderRepo.GetChildren(e.userID, e.teamID, parent)
+		if err != nil {
+			return nil, err
+		}
+
+		var folderID *uuid.UUID
+		for _, child := range children {
+			if child.Name == segments[i] {
+				folderID = &child.ID
+				break
+			}
+		}
+
+		if folderID == nil {
+			folder, err := e.service.folderRepo.Create(entity.Folder{
+				Name:     segments[i],
+				ParentID: parent,
+				TeamID:   e.teamID,
+				UserID:   uuid.MustParse(e.userID),
+			})
+			if err != nil {
+				return nil, err
+			}
+			e.createdFolders = append(e.createdFolders, folder.ID.String())
+			folderID = &folder.ID
+		}
+
+		e.folders[key] = folderID
+		parent = folderID
+	}
+	return parent, nil
+}
+
+func (e *extraction) add(ctx context.Context, name string, content io.Reader) error {
+	segments, err := archiveEntryPath(name)
+	if err != nil {
+		return err
+	}
+	// metadata macOS adds to archives is not part of what was zipped up
+	if len(segments) == 0 || segments[0] == "__MACOSX" {
+		return nil
+	}
+
+	e.entries++
+	if e.entries > constants.MAX_EXTRACT_ENTRIES {
+		return dto.ErrArchiveExtractLimit
+	}
+
+	folderID, err := e.folder(segments[:len(segments)-1])
+	if err != nil {
+		return err
+	}
+
+	req := dto.CreateFileRequest{
+		Filename: segments[len(segments)-1],
+		TeamID:   teamIDString(e.teamID),
+		Content:  content,
+	}
+	if folderID != nil {
+		req.FolderID = folderID.String()
+	}
+
+	file, err := e.service.Create(ctx, e.userID, req)
+	if err != nil {
+		return err
+	}
+	e.files = append(e.files, file)
+	return nil
+}
+
+// rollback removes the files and folders created so far.
+func (e *extraction) rollback(ctx context.Context) {
+	for _, created := range e.files {
+		file, err := e.service.fileRepo.Get(created.ID)
+		if err == nil {
+			err = e.service.fileRepo.DeleteFile(ctx, file)
+		}
+		if err != nil {
+			log.Printf("error removing extracted file %s: %v", created.ID, err)
+		}
+	}
+
+	if len(e.createdFolders) > 0 {
+		if err := e.service.folderRepo.Delete(e.createdFolders); err != nil {
+			log.Printf("error removing extracted folders: %v", err)
+		}
+	}
+}
+
+// Extract creates a file for every file in a ZIP or gzipped TAR archive,
+// recreating its directories as folders. Nothing is kept when any of the
+// files cannot be extracted.
+func (s *fileService) Extract(ctx context.Context, userID string, req dto.ExtractArchiveRequest) ([]dto.FileResponse, error) {
+	walk, err := archiveFormat(req.Filename)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := resolveLocation(s.auth, s.folderRepo, userID, req.FolderID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// ZIP archives need random access, so the archive is spooled to a
+	// temporary file first
+	tmp, err := os.CreateTemp("", "file-extract-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	content := utils.NewHashingReader(req.Content, s.maxUploadSize, dto.ErrFileSizeExceeded)
+	if _, err := io.Copy(tmp, content); err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	budget := &extractBudget{remaining: constants.MAX_EXTRACT_SIZE_MB * constants.MB}
+	if limit := content.Size() * constants.MAX_EXTRACT_RATIO; limit < budget.remaining {
+		budget.remaining = limit
+	}
+
+	e := &extraction{
+		service: s,
+		userID:  userID,
+		teamID:  target.TeamID,
+		folders: map[string]*uuid.UUID{"": target.FolderID},
+		files:   []dto.FileResponse{},
+	}
+	err = walk(tmp, content.Size(), budget, func(name string, r io.Reader) error {
+		return e.add(ctx, name, r)
+	})
+	if err != nil {
+		e.rollback(ctx)
+		return nil, err
+	}
+	return e.files, nil
+}
@@ -6,10 +6,18 @@ import (
 	"FP-DevOps/entity"
 	"FP-DevOps/repository"
 	"FP-DevOps/utils"
+	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"log"
 	"net/http"
-	"path/filepath"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -18,74 +26,315 @@ import (
 type (
 	FileService interface {
 		Create(context.Context, string, dto.CreateFileRequest) (dto.FileResponse, error)
+		CreateBatch(context.Context, string, func() (dto.CreateFileRequest, error)) ([]dto.FileUploadResult, error)
 		Update(context.Context, string, string, dto.FileUpdate) (dto.FileResponse, error)
 		Delete(context.Context, string, string) error
 		GetFile(context.Context, string, string) (dto.FileResponse, error)
+		SignURL(context.Context, string, string, dto.SignFileURLRequest) (dto.SignedURLResponse, error)
+		GetSignedFile(context.Context, string, string, string) (dto.FileResponse, error)
 		GetPaginated(context.Context, string, dto.PaginationQuery) (dto.FilePaginationResponse, error)
+		Move(context.Context, string, string, dto.MoveFileRequest) (dto.FileResponse, error)
+		CreateVersion(context.Context, string, string, dto.CreateFileRequest) (dto.FileResponse, error)
+		GetVersions(context.Context, string, string) ([]dto.FileVersionResponse, error)
+		GetVersion(context.Context, string, string, int) (dto.FileResponse, error)
+		RestoreVersion(context.Context, string, string, int) (dto.FileResponse, error)
+		GetTrash(context.Context, string, dto.PaginationQuery) (dto.FilePaginationResponse, error)
+		Restore(context.Context, string, string) (dto.FileResponse, error)
+		DeletePermanently(context.Context, string, string) error
+		PurgeTrash(context.Context) error
+		CheckUpload(context.Context, string, string, int64) error
+		GetThumbnail(context.Context, string, string, int) (dto.FileResponse, error)
+		GetPreview(context.Context, string, string, int) (dto.FilePreviewResponse, error)
+		GetSharePage(context.Context, string, string) (dto.SharePageResponse, error)
+		GetArchive(context.Context, string, dto.CreateArchiveRequest) (dto.ArchiveResponse, error)
+		WriteArchive(context.Context, string, dto.ArchiveResponse, io.Writer) error
+		Extract(context.Context, string, dto.ExtractArchiveRequest) ([]dto.FileResponse, error)
 	}
 
 	fileService struct {
-		fileRepo repository.FileRepository
+		fileRepo         repository.FileRepository
+		userRepo         repository.UserRepository
+		folderRepo       repository.FolderRepository
+		auth             Authorizer
+		thumbnails       ThumbnailService
+		maxUploadSize    int64
+		versionRetention int
+		trashRetention   time.Duration
+		signingKey       []byte
 	}
 )
 
-func NewFileService(ur repository.FileRepository) FileService {
+func NewFileService(fr repository.FileRepository, ur repository.UserRepository, folderRepo repository.FolderRepository, auth Authorizer, thumbnails ThumbnailService) FileService {
 	return &fileService{
-		fileRepo: ur,
+		fileRepo:         fr,
+		userRepo:         ur,
+		folderRepo:       folderRepo,
+		auth:             auth,
+		thumbnails:       thumbnails,
+		maxUploadSize:    maxUploadSize(),
+		versionRetention: versionRetention(),
+		trashRetention:   trashRetention(),
+		signingKey:       urlSigningKey(),
 	}
 }
 
-func (s *fileService) Create(ctx context.Context, userID string, req dto.CreateFileRequest) (dto.FileResponse, error) {
+func maxUploadSize() int64 {
+	size, err := strconv.ParseInt(os.Getenv("MAX_UPLOAD_SIZE_MB"), 10, 64)
+	if err != nil || size <= 0 {
+		size = constants.DEFAULT_MAX_UPLOAD_SIZE_MB
+	}
+	return size * constants.MB
+}
 
-	if req.File.Size > 20*constants.MB {
-		return dto.FileResponse{}, dto.ErrFileSizeExceeded
+func versionRetention() int {
+	retention, err := strconv.Atoi(os.Getenv("FILE_VERSION_RETENTION"))
+	if err != nil || retention < 0 {
+		retention = constants.DEFAULT_FILE_VERSION_RETENTION
 	}
+	return retention
+}
 
-	file, err := req.File.Open()
-	defer file.Close()
+// CheckUpload checks ahead of an upload that the target folder exists and
+// that size bytes fit in the user's quota.
+func (s *fileService) CheckUpload(ctx context.Context, userID, folderID string, size int64) error {
+	if _, err := resolveLocation(s.auth, s.folderRepo, userID, folderID, ""); err != nil {
+		return err
+	}
+
+	usage, err := storageUsage(s.userRepo, userID)
 	if err != nil {
-		return dto.FileResponse{}, err
+		return err
 	}
 
-	// buffer := make([]byte, 512)
-	buffer, err := io.ReadAll(file)
+	if usage.Used+size > usage.Limit {
+		return dto.ErrStorageQuotaExceeded
+	}
+	return nil
+}
+
+// writeContent stores content as a blob for the user and returns where it
+// went along with the user's quota, which the caller checks again when saving
+// the file. The caller owns the blob reference taken on the content.
+func (s *fileService) writeContent(ctx context.Context, userID string, r io.Reader) (entity.FileVersion, int64, error) {
+	usage, err := storageUsage(s.userRepo, userID)
 	if err != nil {
-		return dto.FileResponse{}, err
+		return entity.FileVersion{}, 0, err
+	}
+	if usage.Used >= usage.Limit {
+		return entity.FileVersion{}, 0, dto.ErrStorageQuotaExceeded
 	}
 
-	if _, err := file.Seek(0, io.SeekStart); err != nil {
-		return dto.FileResponse{}, err
+	// the upload stops as soon as it crosses whichever of the upload limit
+	// and the remaining quota comes first
+	limit, limitErr := s.maxUploadSize, dto.ErrFileSizeExceeded
+	if remaining := usage.Limit - usage.Used; remaining < limit {
+		limit, limitErr = remaining, dto.ErrStorageQuotaExceeded
 	}
 
-	fileType := http.DetectContentType(buffer)
-	fileExt := filepath.Ext(req.File.Filename)
-	fileID := uuid.New()
-	fileName := fileID.String() + fileExt
+	// content that can be read again, like the files batch uploads are
+	// spooled to, is hashed in place and then stored from the start
+	seeker, seekable := r.(io.ReadSeeker)
+	start := int64(0)
+	if seekable {
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			seekable = false
+		}
+	}
+
+	// only the first 512 bytes are buffered for MIME sniffing, the rest of the
+	// content is spooled to a temporary file while its size and checksum are
+	// computed, as the checksum decides where the content is stored
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return entity.FileVersion{}, 0, err
+	}
+	head = head[:n]
 
-	filePath, err := s.fileRepo.WriteFile(userID, fileName, buffer)
+	content := utils.NewHashingReader(io.MultiReader(bytes.NewReader(head), r), limit, limitErr)
+	var body io.Reader
+	if seekable {
+		if _, err := io.Copy(io.Discard, content); err != nil {
+			return entity.FileVersion{}, 0, err
+		}
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return entity.FileVersion{}, 0, err
+		}
+		body = io.LimitReader(seeker, content.Size())
+	} else {
+		tmp, err := os.CreateTemp("", "file-upload-*")
+		if err != nil {
+			return entity.FileVersion{}, 0, err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		if _, err := io.Copy(tmp, content); err != nil {
+			return entity.FileVersion{}, 0, err
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return entity.FileVersion{}, 0, err
+		}
+		body = tmp
+	}
+
+	filePath, err := s.fileRepo.WriteBlob(ctx, content.Checksum(), body, content.Size())
+	if err != nil {
+		return entity.FileVersion{}, 0, err
+	}
+
+	return entity.FileVersion{
+		Path:     filePath,
+		Size:     content.Size(),
+		MimeType: http.DetectContentType(head),
+		Checksum: content.Checksum(),
+	}, usage.Limit, nil
+}
+
+// Create uploads a file to the user's own storage or to a team, the file
+// counts against the quota of the user uploading it either way.
+func (s *fileService) Create(ctx context.Context, userID string, req dto.CreateFileRequest) (dto.FileResponse, error) {
+	target, err := resolveLocation(s.auth, s.folderRepo, userID, req.FolderID, req.TeamID)
 	if err != nil {
 		return dto.FileResponse{}, err
 	}
 
+	content, quota, err := s.writeContent(ctx, userID, req.Content)
+	if err != nil {
+		return dto.FileResponse{}, err
+	}
+
+	fileID := uuid.New()
+	if req.ID != "" {
+		fileID = uuid.MustParse(req.ID)
+	}
+
 	fileEntity := entity.File{
-		ID:       fileID,
-		Filename: utils.SanitizeFilename(req.File.Filename),
-		Size:     req.File.Size,
-		MimeType: fileType,
-		UserID:   uuid.MustParse(userID),
-		Path:     filePath,
+		ID:         fileID,
+		Filename:   utils.SanitizeFilename(req.Filename),
+		Size:       content.Size,
+		MimeType:   content.MimeType,
+		Checksum:   content.Checksum,
+		UserID:     uuid.MustParse(userID),
+		FolderID:   target.FolderID,
+		TeamID:     target.TeamID,
+		Path:       content.Path,
+		Version:    1,
+		ModifiedAt: time.Now(),
 	}
-	if _, err := s.fileRepo.Create(fileEntity); err != nil {
+	if _, err := s.fileRepo.Create(fileEntity, quota); err != nil {
+		s.fileRepo.ReleaseBlob(ctx, content.Checksum)
 		return dto.FileResponse{}, err
 	}
+	s.thumbnails.Enqueue(fileEntity)
 
 	return dto.FileResponse{
 		ID:        fileEntity.ID.String(),
 		Filename:  fileEntity.Filename,
 		Size:      fileEntity.Size,
 		MimeType:  fileEntity.MimeType,
+		Checksum:  fileEntity.Checksum,
 		Shareable: fileEntity.Shareable,
-	}, err
+		FolderID:  folderIDResponse(fileEntity.FolderID),
+		TeamID:    teamIDResponse(fileEntity.TeamID),
+		Version:   fileEntity.Version,
+	}, nil
+}
+
+// spoolUpload copies at most limit bytes of r to a temporary file, which the
+// caller removes.
+func spoolUpload(r io.Reader, limit int64) (*os.File, error) {
+	tmp, err := os.CreateTemp("", "file-batch-*")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.Copy(tmp, io.LimitReader(r, limit)); err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return tmp, nil
+}
+
+// CreateBatch creates the files next yields until it returns io.EOF. Each one
+// is spooled to a temporary file as it is read, so reading the next file does
+// not wait for storage while a bounded number of files are stored at once. A
+// file failing does not stop the others, its error is in its result.
+func (s *fileService) CreateBatch(ctx context.Context, userID string, next func() (dto.CreateFileRequest, error)) ([]dto.FileUploadResult, error) {
+	var (
+		results   []*dto.FileUploadResult
+		wg        sync.WaitGroup
+		semaphore = make(chan struct{}, constants.UPLOAD_CONCURRENCY)
+		readErr   error
+	)
+
+	for {
+		req, err := next()
+		if err != nil {
+			if err != io.EOF {
+				readErr = err
+			}
+			break
+		}
+
+		result := &dto.FileUploadResult{Filename: req.Filename}
+		results = append(results, result)
+		if len(results) > constants.MAX_FILES_PER_UPLOAD {
+			result.Err = dto.ErrTooManyFiles
+			continue
+		}
+
+		// one byte over the limit is enough for Create to reject the file
+		tmp, err := spoolUpload(req.Content, s.maxUploadSize+1)
+		if err != nil {
+			result.Err = err
+			continue
+		}
+		req.Content = tmp
+
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func(req dto.CreateFileRequest, result *dto.FileUploadResult, tmp *os.File) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			defer os.Remove(tmp.Name())
+			defer tmp.Close()
+
+			file, err := s.Create(ctx, userID, req)
+			if err != nil {
+				result.Err = err
+				return
+			}
+			result.File = &file
+		}(req, result, tmp)
+	}
+	wg.Wait()
+
+	res := make([]dto.FileUploadResult, 0, len(results))
+	for _, result := range results {
+		res = append(res, *result)
+	}
+	return res, readErr
+}
+
+// modTime is when the current content of the file was uploaded.
+func modTime(file entity.File) time.Time {
+	if file.ModifiedAt.IsZero() {
+		return file.CreatedAt
+	}
+	return file.ModifiedAt
+}
+
+func trashRetention() time.Duration {
+	days, err := strconv.ParseInt(os.Getenv("TRASH_RETENTION_DAYS"), 10, 64)
+	if err != nil || days <= 0 {
+		days = constants.DEFAULT_TRASH_RETENTION_DAYS
+	}
+	return time.Duration(days) * 24 * time.Hour
 }
 
 func (s *fileService) Update(ctx context.Context, userID, fileID string, req dto.FileUpdate) (dto.FileResponse, error) {
@@ -97,8 +346,14 @@ func (s *fileService) Update(ctx context.Context, userID, fileID string, req dto
 		return dto.FileResponse{}, err
 	}
 
-	if file.UserID.String() != userID {
-		return dto.FileResponse{}, dto.ErrUnauthorizedFileAccess
+	// users the file is shared with may rename it when they can edit it,
+	// only those managing it decide who else it is shared with
+	permission := constants.ENUM_PERMISSION_EDIT
+	if req.Shareable != nil {
+		permission = constants.ENUM_PERMISSION_MANAGE
+	}
+	if err := s.auth.CheckFile(file, userID, permission); err != nil {
+		return dto.FileResponse{}, err
 	}
 
 	if _, err := s.fileRepo.Update(entity.File{
@@ -114,7 +369,11 @@ func (s *fileService) Update(ctx context.Context, userID, fileID string, req dto
 		Filename:  req.Filename,
 		Size:      file.Size,
 		MimeType:  file.MimeType,
+		Checksum:  file.Checksum,
 		Shareable: req.Shareable,
+		FolderID:  folderIDResponse(file.FolderID),
+		TeamID:    teamIDResponse(file.TeamID),
+		Version:   file.Version,
 	}, nil
 }
 
@@ -127,25 +386,64 @@ func (s *fileService) Delete(ctx context.Context, userID, fileID string) error {
 		return err
 	}
 
-	if file.UserID.String() != userID {
-		return dto.ErrUnauthorizedFileAccess
+	if err := s.auth.CheckFile(file, userID, constants.ENUM_PERMISSION_MANAGE); err != nil {
+		return err
 	}
 
-	if err := s.fileRepo.DeleteFile(file); err != nil {
-		return err
+	// the content stays in the uploader's storage until the file is deleted
+	// from the trash, which is the uploader's trash for files of a team too
+	return s.fileRepo.Delete(fileID)
+}
+
+// checkView fails unless the file is public or the user may view it.
+func (s *fileService) checkView(file entity.File, userID string) error {
+	if file.Shareable != nil && *file.Shareable {
+		return nil
 	}
+	return s.auth.CheckFile(file, userID, constants.ENUM_PERMISSION_VIEW)
+}
 
-	if err := s.fileRepo.Delete(fileID); err != nil {
+func (s *fileService) GetFile(ctx context.Context, userID, fileID string) (dto.FileResponse, error) {
+	file, err := s.fileRepo.Get(fileID)
+	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return dto.ErrFileNotFound
+			return dto.FileResponse{}, dto.ErrFileNotFound
 		}
-		return err
+		return dto.FileResponse{}, err
 	}
 
-	return nil
+	if err := s.checkView(file, userID); err != nil {
+		return dto.FileResponse{}, err
+	}
+
+	return s.download(ctx, file)
 }
 
-func (s *fileService) GetFile(ctx context.Context, userID, fileID string) (dto.FileResponse, error) {
+// download opens the current content of the file to send it.
+func (s *fileService) download(ctx context.Context, file entity.File) (dto.FileResponse, error) {
+	content, err := s.fileRepo.OpenFile(ctx, file)
+	if err != nil {
+		return dto.FileResponse{}, err
+	}
+
+	return dto.FileResponse{
+		ID:        file.ID.String(),
+		Filename:  file.Filename,
+		Size:      file.Size,
+		MimeType:  file.MimeType,
+		Checksum:  file.Checksum,
+		Shareable: file.Shareable,
+		FolderID:  folderIDResponse(file.FolderID),
+		TeamID:    teamIDResponse(file.TeamID),
+		Version:   file.Version,
+		ModTime:   modTime(file),
+		Content:   content,
+	}, nil
+}
+
+// GetThumbnail returns a thumbnail of the file, visible to the same users as
+// the file itself.
+func (s *fileService) GetThumbnail(ctx context.Context, userID, fileID string, size int) (dto.FileResponse, error) {
 	file, err := s.fileRepo.Get(fileID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -154,23 +452,156 @@ func (s *fileService) GetFile(ctx context.Context, userID, fileID string) (dto.F
 		return dto.FileResponse{}, err
 	}
 
-	if file.UserID.String() != userID && !*file.Shareable {
+	if err := s.checkView(file, userID); err != nil {
+		return dto.FileResponse{}, err
+	}
+
+	content, mimeType, err := s.thumbnails.Open(ctx, file, size)
+	if err != nil {
+		return dto.FileResponse{}, err
+	}
+
+	return dto.FileResponse{
+		ID:       file.ID.String(),
+		Filename: file.Filename,
+		MimeType: mimeType,
+		// the checksum is served as ETag, which must differ between sizes
+		Checksum:  fmt.Sprintf("%s-%d", file.Checksum, size),
+		Shareable: file.Shareable,
+		FolderID:  folderIDResponse(file.FolderID),
+		TeamID:    teamIDResponse(file.TeamID),
+		Version:   file.Version,
+		ModTime:   modTime(file),
+		Content:   content,
+	}, nil
+}
+
+// Move moves a file within the user's own storage or within its team, the
+// root being the root of the team for files of a team.
+func (s *fileService) Move(ctx context.Context, userID, fileID string, req dto.MoveFileRequest) (dto.FileResponse, error) {
+	file, err := getFile(s.auth, s.fileRepo, userID, fileID, constants.ENUM_PERMISSION_EDIT)
+	if err != nil {
+		return dto.FileResponse{}, err
+	}
+
+	// folders are not shared, so only the owner moves a file of their own
+	// storage around
+	if file.TeamID == nil && file.UserID.String() != userID {
 		return dto.FileResponse{}, dto.ErrUnauthorizedFileAccess
 	}
 
-	data, err := s.fileRepo.ReadFile(file)
+	target, err := resolveLocation(s.auth, s.folderRepo, userID, req.FolderID, teamIDString(file.TeamID))
 	if err != nil {
 		return dto.FileResponse{}, err
 	}
+	if !sameTeam(target.TeamID, file.TeamID) {
+		return dto.FileResponse{}, dto.ErrTeamMismatch
+	}
+	folderID := target.FolderID
+
+	if err := s.fileRepo.Move(fileID, folderID); err != nil {
+		return dto.FileResponse{}, err
+	}
 
 	return dto.FileResponse{
-		Content:   data,
+		ID:        file.ID.String(),
 		Filename:  file.Filename,
+		Size:      file.Size,
 		MimeType:  file.MimeType,
+		Checksum:  file.Checksum,
 		Shareable: file.Shareable,
+		FolderID:  folderIDResponse(folderID),
+		TeamID:    teamIDResponse(file.TeamID),
+		Version:   file.Version,
 	}, nil
 }
 
+// fileSortColumns maps the sorts the file listing accepts to their column.
+var fileSortColumns = map[string]string{
+	"name":       "filename",
+	"size":       "size",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"mime_type":  "mime_type",
+}
+
+var mimeTypePrefixPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.+-]*(/[a-z0-9.+-]*)?$`)
+
+// parseFilterTime reads a bound of a date range, a date stands for its start
+// in UTC.
+func parseFilterTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if t, err = time.Parse(time.DateOnly, value); err != nil {
+			return nil, dto.ErrInvalidFilter
+		}
+	}
+	return &t, nil
+}
+
+// fileFilter checks the sort and filters of the file listing against what
+// it supports, only known columns end up in the query.
+func fileFilter(req dto.PaginationQuery) (dto.FileFilter, error) {
+	filter := dto.FileFilter{
+		Search:    req.Search,
+		FolderID:  req.FolderID,
+		TeamID:    req.TeamID,
+		MinSize:   req.MinSize,
+		MaxSize:   req.MaxSize,
+		Shareable: req.Shareable,
+	}
+
+	sort, order := req.Sort, strings.ToLower(req.Order)
+	if sort == "" {
+		sort = constants.DEFAULT_FILE_SORT
+	}
+	if order == "" {
+		order = constants.DEFAULT_FILE_ORDER
+	}
+	column, ok := fileSortColumns[sort]
+	if !ok || (order != "asc" && order != "desc") {
+		return dto.FileFilter{}, dto.ErrInvalidSort
+	}
+	filter.Sort, filter.Desc = column, order == "desc"
+
+	if req.Tag != "" {
+		tag, err := normalizeTagName(req.Tag)
+		if err != nil {
+			return dto.FileFilter{}, err
+		}
+		filter.Tag = tag
+	}
+
+	if req.MimeType != "" {
+		filter.MimeType = strings.ToLower(req.MimeType)
+		if !mimeTypePrefixPattern.MatchString(filter.MimeType) {
+			return dto.FileFilter{}, dto.ErrInvalidFilter
+		}
+	}
+
+	if (req.MinSize != nil && *req.MinSize < 0) || (req.MaxSize != nil && *req.MaxSize < 0) {
+		return dto.FileFilter{}, dto.ErrInvalidFilter
+	}
+	if req.MinSize != nil && req.MaxSize != nil && *req.MinSize > *req.MaxSize {
+		return dto.FileFilter{}, dto.ErrInvalidFilter
+	}
+
+	var err error
+	if filter.CreatedAfter, err = parseFilterTime(req.CreatedAfter); err != nil {
+		return dto.FileFilter{}, err
+	}
+	if filter.CreatedBefore, err = parseFilterTime(req.CreatedBefore); err != nil {
+		return dto.FileFilter{}, err
+	}
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		return dto.FileFilter{}, dto.ErrInvalidFilter
+	}
+	return filter, nil
+}
+
 func (s *fileService) GetPaginated(ctx context.Context, userID string, req dto.PaginationQuery) (dto.FilePaginationResponse, error) {
 	var limit int
 	var page int
@@ -185,19 +616,53 @@ func (s *fileService) GetPaginated(ctx context.Context, userID string, req dto.P
 		page = constants.ENUM_PAGINATION_PAGE
 	}
 
-	rsvps, maxPage, count, err := s.fileRepo.GetPagination(userID, req.Search, limit, page)
+	filter, err := fileFilter(req)
+	if err != nil {
+		return dto.FilePaginationResponse{}, err
+	}
+
+	if req.TeamID != "" {
+		if _, err := s.auth.CheckTeam(req.TeamID, userID, constants.ENUM_TEAM_ROLE_VIEWER); err != nil {
+			return dto.FilePaginationResponse{}, err
+		}
+	}
+
+	if req.FolderID != "" && req.FolderID != constants.ROOT_FOLDER_ID {
+		folder, err := getFolder(s.auth, s.folderRepo, userID, req.FolderID, constants.ENUM_PERMISSION_VIEW)
+		if err != nil {
+			return dto.FilePaginationResponse{}, err
+		}
+		if req.TeamID != "" && teamIDString(folder.TeamID) != req.TeamID {
+			return dto.FilePaginationResponse{}, dto.ErrTeamMismatch
+		}
+
+		// a folder of a team lists the files every member put in it
+		filter.TeamID = teamIDString(folder.TeamID)
+	}
+
+	rsvps, maxPage, count, err := s.fileRepo.GetPagination(userID, filter, limit, page)
 	if err != nil {
 		return dto.FilePaginationResponse{}, err
 	}
 
 	var result []dto.FileResponse
 	for _, rsvp := range rsvps {
+		var tags []string
+		for _, fileTag := range rsvp.Tags {
+			tags = append(tags, fileTag.Name)
+		}
+
 		result = append(result, dto.FileResponse{
 			ID:        rsvp.ID.String(),
 			Filename:  rsvp.Filename,
 			Size:      rsvp.Size,
 			MimeType:  rsvp.MimeType,
+			Checksum:  rsvp.Checksum,
 			Shareable: rsvp.Shareable,
+			FolderID:  folderIDResponse(rsvp.FolderID),
+			TeamID:    teamIDResponse(rsvp.TeamID),
+			Version:   rsvp.Version,
+			Tags:      tags,
 		})
 	}
 
@@ -211,3 +676,291 @@ func (s *fileService) GetPaginated(ctx context.Context, userID string, req dto.P
 		},
 	}, nil
 }
+
+// getFile returns the file if the user has at least the permission on it.
+func getFile(auth Authorizer, fileRepo repository.FileRepository, userID, fileID, permission string) (entity.File, error) {
+	if _, err := uuid.Parse(fileID); err != nil {
+		return entity.File{}, dto.ErrFileNotFound
+	}
+
+	file, err := fileRepo.Get(fileID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entity.File{}, dto.ErrFileNotFound
+		}
+		return entity.File{}, err
+	}
+
+	if err := auth.CheckFile(file, userID, permission); err != nil {
+		return entity.File{}, err
+	}
+	return file, nil
+}
+
+// pruneVersions only logs failures, the new version is in place already and
+// the next one prunes again.
+func (s *fileService) pruneVersions(ctx context.Context, fileID string) {
+	if err := s.fileRepo.PruneVersions(ctx, fileID, s.versionRetention); err != nil {
+		log.Printf("error pruning versions of file %s: %v", fileID, err)
+	}
+}
+
+// CreateVersion replaces the content of a file, keeping its previous content
+// as a version. The new content counts against the quota of whoever uploaded
+// the file, like its previous versions.
+func (s *fileService) CreateVersion(ctx context.Context, userID, fileID string, req dto.CreateFileRequest) (dto.FileResponse, error) {
+	previous, err := getFile(s.auth, s.fileRepo, userID, fileID, constants.ENUM_PERMISSION_EDIT)
+	if err != nil {
+		return dto.FileResponse{}, err
+	}
+
+	content, quota, err := s.writeContent(ctx, previous.UserID.String(), req.Content)
+	if err != nil {
+		return dto.FileResponse{}, err
+	}
+
+	file, err := s.fileRepo.ReplaceContent(fileID, content, quota)
+	if err != nil {
+		s.fileRepo.ReleaseBlob(ctx, content.Checksum)
+		if err == gorm.ErrRecordNotFound {
+			return dto.FileResponse{}, dto.ErrFileNotFound
+		}
+		return dto.FileResponse{}, err
+	}
+	s.pruneVersions(ctx, fileID)
+	s.thumbnails.Enqueue(file)
+
+	return dto.FileResponse{
+		ID:        file.ID.String(),
+		Filename:  file.Filename,
+		Size:      file.Size,
+		MimeType:  file.MimeType,
+		Checksum:  file.Checksum,
+		Shareable: file.Shareable,
+		FolderID:  folderIDResponse(file.FolderID),
+		TeamID:    teamIDResponse(file.TeamID),
+		Version:   file.Version,
+	}, nil
+}
+
+// GetVersions lists the current content of a file followed by its previous
+// versions, newest first.
+func (s *fileService) GetVersions(ctx context.Context, userID, fileID string) ([]dto.FileVersionResponse, error) {
+	file, err := getFile(s.auth, s.fileRepo, userID, fileID, constants.ENUM_PERMISSION_VIEW)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := s.fileRepo.GetVersions(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []dto.FileVersionResponse{{
+		Version:    file.Version,
+		Size:       file.Size,
+		MimeType:   file.MimeType,
+		Checksum:   file.Checksum,
+		ModifiedAt: modTime(file),
+		Current:    true,
+	}}
+	for _, version := range versions {
+		result = append(result, dto.FileVersionResponse{
+			Version:    version.Version,
+			Size:       version.Size,
+			MimeType:   version.MimeType,
+			Checksum:   version.Checksum,
+			ModifiedAt: version.ModifiedAt,
+		})
+	}
+	return result, nil
+}
+
+func (s *fileService) GetVersion(ctx context.Context, userID, fileID string, version int) (dto.FileResponse, error) {
+	file, err := getFile(s.auth, s.fileRepo, userID, fileID, constants.ENUM_PERMISSION_VIEW)
+	if err != nil {
+		return dto.FileResponse{}, err
+	}
+
+	// the current version is not stored as a version row
+	content := entity.FileVersion{
+		Version:    file.Version,
+		Path:       file.Path,
+		Size:       file.Size,
+		MimeType:   file.MimeType,
+		Checksum:   file.Checksum,
+		ModifiedAt: modTime(file),
+	}
+	if version != file.Version {
+		content, err = s.fileRepo.GetVersion(fileID, version)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return dto.FileResponse{}, dto.ErrFileVersionNotFound
+			}
+			return dto.FileResponse{}, err
+		}
+	}
+
+	object, err := s.fileRepo.OpenFile(ctx, entity.File{Path: content.Path})
+	if err != nil {
+		return dto.FileResponse{}, err
+	}
+
+	return dto.FileResponse{
+		ID:        file.ID.String(),
+		Filename:  file.Filename,
+		Size:      content.Size,
+		MimeType:  content.MimeType,
+		Checksum:  content.Checksum,
+		Shareable: file.Shareable,
+		FolderID:  folderIDResponse(file.FolderID),
+		TeamID:    teamIDResponse(file.TeamID),
+		Version:   content.Version,
+		ModTime:   content.ModifiedAt,
+		Content:   object,
+	}, nil
+}
+
+// RestoreVersion makes a previous version the current content again, the
+// content it replaces is kept as a version.
+func (s *fileService) RestoreVersion(ctx context.Context, userID, fileID string, version int) (dto.FileResponse, error) {
+	if _, err := getFile(s.auth, s.fileRepo, userID, fileID, constants.ENUM_PERMISSION_EDIT); err != nil {
+		return dto.FileResponse{}, err
+	}
+
+	file, err := s.fileRepo.RestoreVersion(fileID, version)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dto.FileResponse{}, dto.ErrFileVersionNotFound
+		}
+		return dto.FileResponse{}, err
+	}
+	s.pruneVersions(ctx, fileID)
+	s.thumbnails.Enqueue(file)
+
+	return dto.FileResponse{
+		ID:        file.ID.String(),
+		Filename:  file.Filename,
+		Size:      file.Size,
+		MimeType:  file.MimeType,
+		Checksum:  file.Checksum,
+		Shareable: file.Shareable,
+		FolderID:  folderIDResponse(file.FolderID),
+		TeamID:    teamIDResponse(file.TeamID),
+		Version:   file.Version,
+	}, nil
+}
+
+// getTrashedFile returns the file if it is in the trash of the user.
+func (s *fileService) getTrashedFile(userID, fileID string) (entity.File, error) {
+	file, err := s.fileRepo.GetTrashed(fileID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entity.File{}, dto.ErrFileNotFound
+		}
+		return entity.File{}, err
+	}
+
+	if file.UserID.String() != userID {
+		return entity.File{}, dto.ErrUnauthorizedFileAccess
+	}
+	return file, nil
+}
+
+func (s *fileService) GetTrash(ctx context.Context, userID string, req dto.PaginationQuery) (dto.FilePaginationResponse, error) {
+	limit := req.PerPage
+	if limit <= 0 {
+		limit = constants.ENUM_PAGINATION_LIMIT
+	}
+
+	page := req.Page
+	if page <= 0 {
+		page = constants.ENUM_PAGINATION_PAGE
+	}
+
+	files, maxPage, count, err := s.fileRepo.GetTrashPagination(userID, limit, page)
+	if err != nil {
+		return dto.FilePaginationResponse{}, err
+	}
+
+	var result []dto.FileResponse
+	for _, file := range files {
+		deletedAt := file.DeletedAt.Time
+		result = append(result, dto.FileResponse{
+			ID:        file.ID.String(),
+			Filename:  file.Filename,
+			Size:      file.Size,
+			MimeType:  file.MimeType,
+			Checksum:  file.Checksum,
+			Shareable: file.Shareable,
+			FolderID:  folderIDResponse(file.FolderID),
+			TeamID:    teamIDResponse(file.TeamID),
+			Version:   file.Version,
+			DeletedAt: &deletedAt,
+		})
+	}
+
+	return dto.FilePaginationResponse{
+		Data: result,
+		PaginationMetadata: dto.PaginationMetadata{
+			Page:    page,
+			PerPage: limit,
+			MaxPage: maxPage,
+			Count:   count,
+		},
+	}, nil
+}
+
+// Restore moves a file out of the trash, back into its folder if that still
+// exists and to the root otherwise.
+func (s *fileService) Restore(ctx context.Context, userID, fileID string) (dto.FileResponse, error) {
+	file, err := s.getTrashedFile(userID, fileID)
+	if err != nil {
+		return dto.FileResponse{}, err
+	}
+
+	if err := s.fileRepo.Restore(fileID); err != nil {
+		return dto.FileResponse{}, err
+	}
+
+	return dto.FileResponse{
+		ID:        file.ID.String(),
+		Filename:  file.Filename,
+		Size:      file.Size,
+		MimeType:  file.MimeType,
+		Checksum:  file.Checksum,
+		Shareable: file.Shareable,
+		FolderID:  folderIDResponse(file.FolderID),
+		TeamID:    teamIDResponse(file.TeamID),
+		Version:   file.Version,
+	}, nil
+}
+
+// DeletePermanently deletes a file in the trash together with its content.
+func (s *fileService) DeletePermanently(ctx context.Context, userID, fileID string) error {
+	file, err := s.getTrashedFile(userID, fileID)
+	if err != nil {
+		return err
+	}
+
+	return s.fileRepo.DeleteFile(ctx, file)
+}
+
+// PurgeTrash permanently deletes the files that have been in the trash for
+// longer than the trash retention.
+func (s *fileService) PurgeTrash(ctx context.Context) error {
+	files, err := s.fileRepo.GetTrashedBefore(time.Now().Add(-s.trashRetention))
+	if err != nil {
+		return err
+	}
+
+	// a failing file is skipped so it does not hold back the others, it is
+	// retried on the next run
+	for _, file := range files {
+		if err := s.fileRepo.DeleteFile(ctx, file); err != nil {
+			log.Printf("error purging file %s: %v", file.ID, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/entity"
+	"FP-DevOps/repository"
+	"FP-DevOps/utils"
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type (
+	FolderService interface {
+		Create(context.Context, string, dto.CreateFolderRequest) (dto.FolderResponse, error)
+		GetFolder(context.Context, string, string) (dto.FolderContentResponse, error)
+		GetTeamRoot(context.Context, string, string) (dto.FolderContentResponse, error)
+		Rename(context.Context, string, string, dto.FolderUpdate) (dto.FolderResponse, error)
+		Move(context.Context, string, string, dto.MoveFolderRequest) (dto.FolderResponse, error)
+		Delete(context.Context, string, string) error
+	}
+
+	folderService struct {
+		folderRepo repository.FolderRepository
+		auth       Authorizer
+	}
+
+	// location is where a file or folder is put, a nil FolderID is the root
+	// and a nil TeamID the user's own storage.
+	location struct {
+		FolderID *uuid.UUID
+		TeamID   *uuid.UUID
+	}
+)
+
+func NewFolderService(fr repository.FolderRepository, auth Authorizer) FolderService {
+	return &folderService{
+		folderRepo: fr,
+		auth:       auth,
+	}
+}
+
+// folderIDResponse formats the folder ID of a file or folder, nil is the root.
+func folderIDResponse(folderID *uuid.UUID) *string {
+	if folderID == nil {
+		return nil
+	}
+	id := folderID.String()
+	return &id
+}
+
+// teamIDResponse formats the team of a file or folder, nil is the user's own
+// storage.
+func teamIDResponse(teamID *uuid.UUID) *string {
+	return folderIDResponse(teamID)
+}
+
+// teamIDString is the ID of the team of a file or folder, empty for the
+// user's own storage.
+func teamIDString(teamID *uuid.UUID) string {
+	if teamID == nil {
+		return ""
+	}
+	return teamID.String()
+}
+
+func folderResponse(folder entity.Folder) dto.FolderResponse {
+	return dto.FolderResponse{
+		ID:       folder.ID.String(),
+		Name:     folder.Name,
+		ParentID: folderIDResponse(folder.ParentID),
+		TeamID:   teamIDResponse(folder.TeamID),
+	}
+}
+
+// getFolder returns the folder with the given ID if the user has at least the
+// permission on it.
+func getFolder(auth Authorizer, folderRepo repository.FolderRepository, userID, folderID, permission string) (entity.Folder, error) {
+	if _, err := uuid.Parse(folderID); err != nil {
+		return entity.Folder{}, dto.ErrFolderNotFound
+	}
+
+	folder, err := folderRepo.Get(folderID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entity.Folder{}, dto.ErrFolderNotFound
+		}
+		return entity.Folder{}, err
+	}
+
+	if err := auth.CheckFolder(folder, userID, permission); err != nil {
+		return entity.Folder{}, err
+	}
+	return folder, nil
+}
+
+// resolveLocation checks where a file or folder is put, which takes
+// permission to edit the folder. An empty folder ID stands for the root of the
+// team, or of the user's own storage without a team, and a folder outside of
+// the team is a mismatch.
+func resolveLocation(auth Authorizer, folderRepo repository.FolderRepository, userID, folderID, teamID string) (location, error) {
+	if folderID != "" && folderID != constants.ROOT_FOLDER_ID {
+		folder, err := getFolder(auth, folderRepo, userID, folderID, constants.ENUM_PERMISSION_EDIT)
+		if err != nil {
+			return location{}, err
+		}
+		if teamID != "" && teamIDString(folder.TeamID) != teamID {
+			return location{}, dto.ErrTeamMismatch
+		}
+		return location{FolderID: &folder.ID, TeamID: folder.TeamID}, nil
+	}
+
+	if teamID == "" {
+		return location{}, nil
+	}
+	if _, err := auth.CheckTeam(teamID, userID, constants.ENUM_TEAM_ROLE_MEMBER); err != nil {
+		return location{}, err
+	}
+	team := uuid.MustParse(teamID)
+	return location{TeamID: &team}, nil
+}
+
+func (s *folderService) Create(ctx context.Context, userID string, req dto.CreateFolderRequest) (dto.FolderResponse, error) {
+	name := utils.SanitizeFilename(req.Name)
+	if name == "" {
+		return dto.FolderResponse{}, dto.ErrFolderNameRequired
+	}
+
+	parent, err := resolveLocation(s.auth, s.folderRepo, userID, req.ParentID, req.TeamID)
+	if err != nil {
+		return dto.FolderResponse{}, err
+	}
+
+	folder, err := s.folderRepo.Create(entity.Folder{
+		Name:     name,
+		ParentID: parent.FolderID,
+		TeamID:   parent.TeamID,
+		UserID:   uuid.MustParse(userID),
+	})
+	if err != nil {
+		return dto.FolderResponse{}, err
+	}
+
+	return folderResponse(folder), nil
+}
+
+func (s *folderService) GetFolder(ctx context.Context, userID, folderID string) (dto.FolderContentResponse, error) {
+	res := dto.FolderContentResponse{
+		Breadcrumbs: []dto.FolderResponse{},
+		Folders:     []dto.FolderResponse{},
+	}
+
+	var teamID, parentID *uuid.UUID
+	if folderID != "" && folderID != constants.ROOT_FOLDER_ID {
+		folder, err := getFolder(s.auth, s.folderRepo, userID, folderID, constants.ENUM_PERMISSION_VIEW)
+		if err != nil {
+			return dto.FolderContentResponse{}, err
+		}
+
+		ancestors, err := s.folderRepo.GetAncestors(folderID)
+		if err != nil {
+			return dto.FolderContentResponse{}, err
+		}
+		for _, ancestor := range ancestors {
+			res.Breadcrumbs = append(res.Breadcrumbs, folderResponse(ancestor))
+		}
+
+		current := folderResponse(folder)
+		res.Folder = &current
+		teamID, parentID = folder.TeamID, &folder.ID
+	}
+
+	return s.folderContent(userID, teamID, parentID, res)
+}
+
+// GetTeamRoot lists the folders at the root of a team.
+func (s *folderService) GetTeamRoot(ctx context.Context, userID, teamID string) (dto.FolderContentResponse, error) {
+	if _, err := s.auth.CheckTeam(teamID, userID, constants.ENUM_TEAM_ROLE_VIEWER); err != nil {
+		return dto.FolderContentResponse{}, err
+	}
+
+	team := uuid.MustParse(teamID)
+	return s.folderContent(userID, &team, nil, dto.FolderContentResponse{
+		Breadcrumbs: []dto.FolderResponse{},
+		Folders:     []dto.FolderResponse{},
+	})
+}
+
+// folderContent adds the subfolders of parentID to res.
+func (s *folderService) folderContent(userID string, teamID, parentID *uuid.UUID, res dto.FolderContentResponse) (dto.FolderContentResponse, error) {
+	children, err := s.folderRepo.GetChildren(userID, teamID, parentID)
+	if err != nil {
+		return dto.FolderContentResponse{}, err
+	}
+	for _, child := range children {
+		res.Folders = append(res.Folders, folderResponse(child))
+	}
+
+	return res, nil
+}
+
+func (s *folderService) Rename(ctx context.Context, userID, folderID string, req dto.FolderUpdate) (dto.FolderResponse, error) {
+	folder, err := getFolder(s.auth, s.folderRepo, userID, folderID, constants.ENUM_PERMISSION_EDIT)
+	if err != nil {
+		return dto.FolderResponse{}, err
+	}
+
+	name := utils.SanitizeFilename(req.Name)
+	if name == "" {
+		return dto.FolderResponse{}, dto.ErrFolderNameRequired
+	}
+
+	if err := s.folderRepo.Rename(folderID, name); err != nil {
+		return dto.FolderResponse{}, err
+	}
+
+	folder.Name = name
+	return folderResponse(folder), nil
+}
+
+// Move moves a folder within the user's own storage or within its team, the
+// root being the root of the team for folders of a team.
+func (s *folderService) Move(ctx context.Context, userID, folderID string, req dto.MoveFolderRequest) (dto.FolderResponse, error) {
+	folder, err := getFolder(s.auth, s.folderRepo, userID, folderID, constants.ENUM_PERMISSION_EDIT)
+	if err != nil {
+		return dto.FolderResponse{}, err
+	}
+
+	parent, err := resolveLocation(s.auth, s.folderRepo, userID, req.ParentID, teamIDString(folder.TeamID))
+	if err != nil {
+		return dto.FolderResponse{}, err
+	}
+	if !sameTeam(parent.TeamID, folder.TeamID) {
+		return dto.FolderResponse{}, dto.ErrTeamMismatch
+	}
+	parentID := parent.FolderID
+
+	// the new parent may not be the folder itself or nested in it
+	if parentID != nil {
+		ancestors, err := s.folderRepo.GetAncestors(parentID.String())
+		if err != nil {
+			return dto.FolderResponse{}, err
+		}
+		for _, ancestor := range ancestors {
+			if ancestor.ID == folder.ID {
+				return dto.FolderResponse{}, dto.ErrInvalidFolderMove
+			}
+		}
+	}
+
+	if err := s.folderRepo.Move(folderID, parentID); err != nil {
+		return dto.FolderResponse{}, err
+	}
+
+	folder.ParentID = parentID
+	return folderResponse(folder), nil
+}
+
+// Delete removes the folder together with its subfolders, the files in them
+// are moved to the trash of whoever uploaded them.
+func (s *folderService) Delete(ctx context.Context, userID, folderID string) error {
+	if _, err := getFolder(s.auth, s.folderRepo, userID, folderID, constants.ENUM_PERMISSION_MANAGE); err != nil {
+		return err
+	}
+
+	return s.folderRepo.DeleteTree(folderID)
+}
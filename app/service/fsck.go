@@ -0,0 +1,234 @@
+package service
+
+import (
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/repository"
+	"context"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// FsckService checks storage against the database: objects nothing
+	// references, referenced objects that are missing and blob reference
+	// counts that drifted.
+	FsckService interface {
+		Run(context.Context, dto.FsckOptions) (dto.FsckReport, error)
+		// Options are the options of the periodic check, Interval is zero when
+		// it is disabled.
+		Options() dto.FsckOptions
+		Interval() time.Duration
+	}
+
+	fsckService struct {
+		fsckRepo repository.FsckRepository
+		options  dto.FsckOptions
+		interval time.Duration
+	}
+)
+
+func NewFsckService(fr repository.FsckRepository) FsckService {
+	return &fsckService{
+		fsckRepo: fr,
+		options:  fsckOptions(),
+		interval: fsckInterval(),
+	}
+}
+
+func fsckOptions() dto.FsckOptions {
+	mode := os.Getenv("FSCK_MODE")
+	if mode == "" {
+		mode = constants.ENUM_FSCK_MODE_DRY_RUN
+	}
+
+	minutes, err := strconv.ParseInt(os.Getenv("FSCK_GRACE_PERIOD_MINUTES"), 10, 64)
+	if err != nil || minutes < 0 {
+		minutes = constants.DEFAULT_FSCK_GRACE_PERIOD_IN_MINUTES
+	}
+
+	return dto.FsckOptions{
+		Mode:        mode,
+		GracePeriod: time.Duration(minutes) * time.Minute,
+	}
+}
+
+func fsckInterval() time.Duration {
+	hours, err := strconv.ParseInt(os.Getenv("FSCK_INTERVAL_HOURS"), 10, 64)
+	if err != nil || hours <= 0 {
+		return 0
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+func (s *fsckService) Options() dto.FsckOptions {
+	return s.options
+}
+
+func (s *fsckService) Interval() time.Duration {
+	return s.interval
+}
+
+// orphanUpload tells whether key holds a chunk of an upload that no longer
+// exists.
+func orphanUpload(key string, uploads map[string]bool) bool {
+	id := strings.SplitN(strings.TrimPrefix(key, constants.UPLOAD_STORAGE_PREFIX+"/"), "/", 2)[0]
+	return !uploads[id]
+}
+
+// orphanThumbnail tells whether key holds a thumbnail of a blob that no longer
+// exists.
+func orphanThumbnail(key string, checksums map[string]bool) bool {
+	parts := strings.Split(strings.TrimPrefix(key, constants.THUMBNAIL_STORAGE_PREFIX+"/"), "/")
+	return len(parts) != 3 || !checksums[parts[1]]
+}
+
+func (s *fsckService) Run(ctx context.Context, opts dto.FsckOptions) (dto.FsckReport, error) {
+	switch opts.Mode {
+	case constants.ENUM_FSCK_MODE_DRY_RUN, constants.ENUM_FSCK_MODE_QUARANTINE, constants.ENUM_FSCK_MODE_DELETE:
+	default:
+		return dto.FsckReport{}, dto.ErrInvalidFsckMode
+	}
+	dryRun := opts.Mode == constants.ENUM_FSCK_MODE_DRY_RUN
+	cutoff := time.Now().Add(-opts.GracePeriod)
+
+	// rows are read before listing storage, so content written in between is
+	// recent and left alone by the grace period
+	blobs, err := s.fsckRepo.GetBlobs()
+	if err != nil {
+		return dto.FsckReport{}, err
+	}
+	refs, err := s.fsckRepo.GetContentRefs()
+	if err != nil {
+		return dto.FsckReport{}, err
+	}
+	uploads, err := s.fsckRepo.GetUploadIDs()
+	if err != nil {
+		return dto.FsckReport{}, err
+	}
+	objects, err := s.fsckRepo.ListObjects(ctx)
+	if err != nil {
+		return dto.FsckReport{}, err
+	}
+
+	report := dto.FsckReport{
+		OrphanObjects:      []string{},
+		MissingObjects:     []string{},
+		RefCountMismatches: []dto.FsckRefCount{},
+	}
+
+	stored := make(map[string]bool, len(objects))
+	for _, object := range objects {
+		if !strings.HasPrefix(object.Key, constants.QUARANTINE_STORAGE_PREFIX+"/") {
+			stored[object.Key] = true
+		}
+	}
+	report.Objects = len(stored)
+
+	// blobs nothing uses anymore are orphans together with their row, the
+	// others must have a reference count matching their users. Blobs changed
+	// recently may be in the middle of an upload and are skipped.
+	orphanBlobs := map[string]string{}
+	blobKeys := make(map[string]bool, len(blobs))
+	checksums := make(map[string]bool, len(blobs))
+	for _, blob := range blobs {
+		blobKeys[blob.Path] = true
+		checksums[blob.Checksum] = true
+		if blob.UpdatedAt.After(cutoff) {
+			continue
+		}
+
+		actual := refs[blob.Path]
+		if actual == 0 {
+			orphanBlobs[blob.Path] = blob.Checksum
+			continue
+		}
+		if actual != blob.RefCount {
+			report.RefCountMismatches = append(report.RefCountMismatches, dto.FsckRefCount{
+				Checksum: blob.Checksum,
+				RefCount: blob.RefCount,
+				Actual:   actual,
+			})
+			if !dryRun {
+				fixed, err := s.fsckRepo.SetBlobRefCount(blob.Checksum, actual, cutoff)
+				if err != nil {
+					log.Printf("error fixing reference count of blob %s: %v", blob.Checksum, err)
+				} else if fixed {
+					report.Fixed++
+				}
+			}
+		}
+		if !stored[blob.Path] {
+			report.MissingObjects = append(report.MissingObjects, blob.Path)
+		}
+	}
+
+	// content of files stored before deduplication is referenced directly
+	for key := range refs {
+		if !blobKeys[key] && !stored[key] {
+			report.MissingObjects = append(report.MissingObjects, key)
+		}
+	}
+
+	for path, checksum := range orphanBlobs {
+		report.OrphanObjects = append(report.OrphanObjects, path)
+		if dryRun {
+			continue
+		}
+
+		removed, err := s.fsckRepo.RemoveOrphanBlob(ctx, checksum, cutoff, opts.Mode == constants.ENUM_FSCK_MODE_QUARANTINE)
+		if err != nil {
+			log.Printf("error removing orphan blob %s: %v", checksum, err)
+		} else if removed && opts.Mode == constants.ENUM_FSCK_MODE_QUARANTINE {
+			report.Quarantined++
+		} else if removed {
+			report.Deleted++
+		}
+	}
+
+	// objects without any row, blobs were handled above
+	for _, object := range objects {
+		if !stored[object.Key] || blobKeys[object.Key] || object.LastModified.After(cutoff) {
+			continue
+		}
+
+		var orphan bool
+		if strings.HasPrefix(object.Key, constants.UPLOAD_STORAGE_PREFIX+"/") {
+			orphan = orphanUpload(object.Key, uploads)
+		} else if strings.HasPrefix(object.Key, constants.THUMBNAIL_STORAGE_PREFIX+"/") {
+			orphan = orphanThumbnail(object.Key, checksums)
+		} else {
+			orphan = refs[object.Key] == 0
+		}
+		if !orphan {
+			continue
+		}
+
+		report.OrphanObjects = append(report.OrphanObjects, object.Key)
+		if dryRun {
+			continue
+		}
+
+		if opts.Mode == constants.ENUM_FSCK_MODE_QUARANTINE {
+			if err := s.fsckRepo.QuarantineObject(ctx, object.Key); err != nil {
+				log.Printf("error quarantining %s: %v", object.Key, err)
+				continue
+			}
+			report.Quarantined++
+		} else {
+			if err := s.fsckRepo.DeleteObject(ctx, object.Key); err != nil {
+				log.Printf("error deleting %s: %v", object.Key, err)
+				continue
+			}
+			report.Deleted++
+		}
+	}
+
+	sort.Strings(report.OrphanObjects)
+	sort.Strings(report.MissingObjects)
+	return report, nil
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/entity"
+	"FP-DevOps/repository"
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type (
+	// GrantService shares files with other users, with permission to view,
+	// edit or manage them.
+	GrantService interface {
+		Grant(context.Context, string, string, dto.GrantFileRequest) (dto.FileGrantResponse, error)
+		GetGrants(context.Context, string, string) ([]dto.FileGrantResponse, error)
+		Revoke(context.Context, string, string, string) error
+		GetSharedWithMe(context.Context, string, dto.PaginationQuery) (dto.SharedFilePaginationResponse, error)
+	}
+
+	grantService struct {
+		grantRepo repository.FileGrantRepository
+		fileRepo  repository.FileRepository
+		userRepo  repository.UserRepository
+		auth      Authorizer
+	}
+)
+
+func NewGrantService(gr repository.FileGrantRepository, fileRepo repository.FileRepository, userRepo repository.UserRepository, auth Authorizer) GrantService {
+	return &grantService{
+		grantRepo: gr,
+		fileRepo:  fileRepo,
+		userRepo:  userRepo,
+		auth:      auth,
+	}
+}
+
+// getManagedFile returns the file if the user may manage who it is shared
+// with.
+func (s *grantService) getManagedFile(userID, fileID string) (entity.File, error) {
+	return getFile(s.auth, s.fileRepo, userID, fileID, constants.ENUM_PERMISSION_MANAGE)
+}
+
+func fileGrantResponse(grant entity.FileGrant) dto.FileGrantResponse {
+	return dto.FileGrantResponse{
+		ID:         grant.ID.String(),
+		FileID:     grant.FileID.String(),
+		UserID:     grant.UserID.String(),
+		Username:   grant.User.Username,
+		Permission: grant.Permission,
+	}
+}
+
+// Grant shares a file with another user, or changes what they may do with
+// it.
+func (s *grantService) Grant(ctx context.Context, userID, fileID string, req dto.GrantFileRequest) (dto.FileGrantResponse, error) {
+	file, err := s.getManagedFile(userID, fileID)
+	if err != nil {
+		return dto.FileGrantResponse{}, err
+	}
+
+	if _, ok := permissionLevels[req.Permission]; !ok {
+		return dto.FileGrantResponse{}, dto.ErrInvalidPermission
+	}
+
+	grantee, err := s.userRepo.GetUserByUsername(req.Username)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dto.FileGrantResponse{}, dto.ErrGranteeNotFound
+		}
+		return dto.FileGrantResponse{}, err
+	}
+	if grantee.ID == file.UserID || grantee.ID.String() == userID {
+		return dto.FileGrantResponse{}, dto.ErrInvalidGrantee
+	}
+
+	grant, err := s.grantRepo.Upsert(entity.FileGrant{
+		FileID:     file.ID,
+		UserID:     grantee.ID,
+		Permission: req.Permission,
+	})
+	if err != nil {
+		return dto.FileGrantResponse{}, err
+	}
+	grant.User = grantee
+
+	return fileGrantResponse(grant), nil
+}
+
+func (s *grantService) GetGrants(ctx context.Context, userID, fileID string) ([]dto.FileGrantResponse, error) {
+	if _, err := s.getManagedFile(userID, fileID); err != nil {
+		return nil, err
+	}
+
+	grants, err := s.grantRepo.GetByFile(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []dto.FileGrantResponse{}
+	for _, grant := range grants {
+		res = append(res, fileGrantResponse(grant))
+	}
+	return res, nil
+}
+
+// Revoke stops sharing a file with a user. Users may also give up the access
+// they were granted themselves.
+func (s *grantService) Revoke(ctx context.Context, userID, fileID, grantID string) error {
+	if _, err := uuid.Parse(grantID); err != nil {
+		return dto.ErrGrantNotFound
+	}
+
+	grant, err := s.grantRepo.Get(grantID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dto.ErrGrantNotFound
+		}
+		return err
+	}
+	if grant.FileID.String() != fileID {
+		return dto.ErrGrantNotFound
+	}
+
+	if grant.UserID.String() != userID {
+		if _, err := s.getManagedFile(userID, fileID); err != nil {
+			return err
+		}
+	}
+	return s.grantRepo.Delete(grantID)
+}
+
+// GetSharedWithMe lists the files other users shared with the user.
+func (s *grantService) GetSharedWithMe(ctx context.Context, userID string, req dto.PaginationQuery) (dto.SharedFilePaginationResponse, error) {
+	limit := req.PerPage
+	if limit <= 0 {
+		limit = constants.ENUM_PAGINATION_LIMIT
+	}
+
+	page := req.Page
+	if page <= 0 {
+		page = constants.ENUM_PAGINATION_PAGE
+	}
+
+	grants, maxPage, count, err := s.grantRepo.GetSharedWithPagination(userID, limit, page)
+	if err != nil {
+		return dto.SharedFilePaginationResponse{}, err
+	}
+
+	result := []dto.SharedFileResponse{}
+	for _, grant := range grants {
+		result = append(result, dto.SharedFileResponse{
+			FileResponse: dto.FileResponse{
+				ID:        grant.File.ID.String(),
+				Filename:  grant.File.Filename,
+				Size:      grant.File.Size,
+				MimeType:  grant.File.MimeType,
+				Checksum:  grant.File.Checksum,
+				Shareable: grant.File.Shareable,
+				Version:   grant.File.Version,
+			},
+			Owner:      grant.File.User.Username,
+			Permission: grant.Permission,
+		})
+	}
+
+	return dto.SharedFilePaginationResponse{
+		Data: result,
+		PaginationMetadata: dto.PaginationMetadata{
+			Page:    page,
+			PerPage: limit,
+			MaxPage: maxPage,
+			Count:   count,
+		},
+	}, nil
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	markdown        = goldmark.New(goldmark.WithExtensions(extension.GFM))
+	markdownPolicy  = bluemonday.UGCPolicy()
+	codeFormatter   = chromahtml.New(chromahtml.WithLineNumbers(true), chromahtml.TabWidth(4))
+	tableDelimiters = map[string]rune{
+		".csv": ',',
+		".tsv": '\t',
+	}
+)
+
+// previewKind decides how a file is rendered from its extension, falling back
+// to plain text for other text files.
+func previewKind(filename, mimeType string) (string, chroma.Lexer) {
+	ext := strings.ToLower(path.Ext(filename))
+	switch {
+	case ext == ".md" || ext == ".markdown":
+		return constants.ENUM_PREVIEW_MARKDOWN, nil
+	case tableDelimiters[ext] != 0:
+		return constants.ENUM_PREVIEW_TABLE, nil
+	}
+
+	if lexer := lexers.Match(filename); lexer != nil {
+		return constants.ENUM_PREVIEW_CODE, lexer
+	}
+	if strings.HasPrefix(mimeType, "text/") {
+		return constants.ENUM_PREVIEW_CODE, lexers.Fallback
+	}
+	return "", nil
+}
+
+// readPreview reads the first limit bytes of content that are rendered,
+// telling whether the rest was cut off.
+func readPreview(content io.Reader, limit int) ([]byte, bool, error) {
+	data, err := io.ReadAll(io.LimitReader(content, int64(limit)+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(data) > limit {
+		return data[:limit], true, nil
+	}
+	return data, false, nil
+}
+
+// GetPreview renders a file for the browser, with the same visibility as
+// downloading it. Markdown becomes sanitized HTML, source code is highlighted
+// and CSV/TSV files are split in pages of rows.
+func (s *fileService) GetPreview(ctx context.Context, userID, fileID string, page int) (dto.FilePreviewResponse, error) {
+	file, err := s.GetFile(ctx, userID, fileID)
+	if err != nil {
+		return dto.FilePreviewResponse{}, err
+	}
+	content := file.Content
+	defer content.Close()
+	file.Content = nil
+
+	kind, lexer := previewKind(file.Filename, file.MimeType)
+	res := dto.FilePreviewResponse{
+		File: file,
+		Kind: kind,
+	}
+
+	switch kind {
+	case constants.ENUM_PREVIEW_MARKDOWN:
+		data, truncated, err := readPreview(content, constants.MAX_PREVIEW_SIZE)
+		if err != nil {
+			return dto.FilePreviewResponse{}, err
+		}
+
+		var buf bytes.Buffer
+		if err := markdown.Convert(data, &buf); err != nil {
+			return dto.FilePreviewResponse{}, err
+		}
+		res.HTML = string(markdownPolicy.SanitizeBytes(buf.Bytes()))
+		res.Truncated = truncated
+	case constants.ENUM_PREVIEW_CODE:
+		data, truncated, err := readPreview(content, constants.MAX_PREVIEW_SIZE)
+		if err != nil {
+			return dto.FilePreviewResponse{}, err
+		}
+
+		tokens, err := chroma.Coalesce(lexer).Tokenise(nil, string(data))
+		if err != nil {
+			return dto.FilePreviewResponse{}, err
+		}
+
+		var buf bytes.Buffer
+		if err := codeFormatter.Format(&buf, styles.Get("github"), tokens); err != nil {
+			return dto.FilePreviewResponse{}, err
+		}
+		res.HTML = buf.String()
+		res.Truncated = truncated
+	case constants.ENUM_PREVIEW_TABLE:
+		if err := readTablePage(&res, content, tableDelimiters[strings.ToLower(path.Ext(file.Filename))], page); err != nil {
+			return dto.FilePreviewResponse{}, err
+		}
+	default:
+		return dto.FilePreviewResponse{}, dto.ErrPreviewUnsupported
+	}
+
+	return res, nil
+}
+
+// readTablePage reads the header and the rows of one page of a table. The
+// whole table is streamed through to count its pages, only the requested rows
+// are kept.
+func readTablePage(res *dto.FilePreviewResponse, content io.Reader, delimiter rune, page int) error {
+	reader := csv.NewReader(content)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		res.Page, res.MaxPage = 1, 1
+		return nil
+	}
+	if err != nil {
+		return dto.ErrInvalidTable
+	}
+	res.Header = header
+
+	if page < 1 {
+		page = 1
+	}
+	start := (page - 1) * constants.PREVIEW_ROWS_PER_PAGE
+
+	// the rows of the page being read are kept as well, so a page past the
+	// end of the table shows the last one
+	var count int
+	var last [][]string
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return dto.ErrInvalidTable
+		}
+
+		if count >= start && count < start+constants.PREVIEW_ROWS_PER_PAGE {
+			res.Rows = append(res.Rows, row)
+		}
+		if count%constants.PREVIEW_ROWS_PER_PAGE == 0 {
+			last = nil
+		}
+		last = append(last, row)
+		count++
+	}
+
+	res.MaxPage = (count + constants.PREVIEW_ROWS_PER_PAGE - 1) / constants.PREVIEW_ROWS_PER_PAGE
+	if res.MaxPage == 0 {
+		res.MaxPage = 1
+	}
+	res.Page = page
+	if page > res.MaxPage {
+		res.Page, res.Rows = res.MaxPage, last
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/entity"
+	"FP-DevOps/repository"
+	"os"
+	"strconv"
+)
+
+func defaultStorageQuota() int64 {
+	quota, err := strconv.ParseInt(os.Getenv("DEFAULT_STORAGE_QUOTA_MB"), 10, 64)
+	if err != nil || quota <= 0 {
+		quota = constants.DEFAULT_STORAGE_QUOTA_MB
+	}
+	return quota * constants.MB
+}
+
+// storageQuota returns the user's own quota when one is set, the default
+// quota otherwise
+func storageQuota(user entity.User) int64 {
+	if user.StorageQuota != nil {
+		return *user.StorageQuota
+	}
+	return defaultStorageQuota()
+}
+
+func storageUsage(userRepo repository.UserRepository, userID string) (dto.StorageUsage, error) {
+	user, err := userRepo.GetUserById(userID)
+	if err != nil {
+		return dto.StorageUsage{}, dto.ErrGetUserById
+	}
+
+	used, err := userRepo.GetStorageUsage(userID)
+	if err != nil {
+		return dto.StorageUsage{}, err
+	}
+
+	return dto.StorageUsage{
+		Used:  used,
+		Limit: storageQuota(user),
+	}, nil
+}
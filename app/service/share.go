@@ -0,0 +1,221 @@
+package service
+
+import (
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/entity"
+	"FP-DevOps/repository"
+	"FP-DevOps/utils"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type (
+	// ShareService manages the links sharing a file with people without an
+	// account, and opens files through them.
+	ShareService interface {
+		Create(context.Context, string, string, dto.CreateShareLinkRequest) (dto.ShareLinkResponse, error)
+		GetByFile(context.Context, string, string) ([]dto.ShareLinkResponse, error)
+		Revoke(context.Context, string, string) (dto.ShareLinkResponse, error)
+		Open(context.Context, string, string, bool) (dto.FileResponse, error)
+	}
+
+	shareService struct {
+		shareLinkRepo repository.ShareLinkRepository
+		fileRepo      repository.FileRepository
+		auth          Authorizer
+	}
+)
+
+func NewShareService(sr repository.ShareLinkRepository, fileRepo repository.FileRepository, auth Authorizer) ShareService {
+	return &shareService{
+		shareLinkRepo: sr,
+		fileRepo:      fileRepo,
+		auth:          auth,
+	}
+}
+
+func shareLinkResponse(link entity.ShareLink) dto.ShareLinkResponse {
+	return dto.ShareLinkResponse{
+		ID:           link.ID.String(),
+		FileID:       link.FileID.String(),
+		Token:        link.Token,
+		URL:          constants.SHARE_LINK_PATH + link.Token,
+		HasPassword:  link.Password != "",
+		ExpiresAt:    link.ExpiresAt,
+		MaxDownloads: link.MaxDownloads,
+		Downloads:    link.Downloads,
+		RevokedAt:    link.RevokedAt,
+		CreatedAt:    link.CreatedAt,
+	}
+}
+
+// shareLinkToken generates a token that cannot be guessed and fits in a URL.
+func shareLinkToken() (string, error) {
+	token := make([]byte, constants.SHARE_LINK_TOKEN_BYTES)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// Create creates a link to a file the user manages.
+func (s *shareService) Create(ctx context.Context, userID, fileID string, req dto.CreateShareLinkRequest) (dto.ShareLinkResponse, error) {
+	file, err := getFile(s.auth, s.fileRepo, userID, fileID, constants.ENUM_PERMISSION_MANAGE)
+	if err != nil {
+		return dto.ShareLinkResponse{}, err
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return dto.ShareLinkResponse{}, dto.ErrInvalidShareLink
+	}
+	if req.MaxDownloads != nil && *req.MaxDownloads < 1 {
+		return dto.ShareLinkResponse{}, dto.ErrInvalidShareLink
+	}
+
+	token, err := shareLinkToken()
+	if err != nil {
+		return dto.ShareLinkResponse{}, err
+	}
+
+	link := entity.ShareLink{
+		Token:        token,
+		FileID:       file.ID,
+		MaxDownloads: req.MaxDownloads,
+	}
+	if req.ExpiresAt != nil {
+		expiresAt := req.ExpiresAt.UTC()
+		link.ExpiresAt = &expiresAt
+	}
+	if req.Password != "" {
+		if link.Password, err = utils.HashPassword(req.Password); err != nil {
+			return dto.ShareLinkResponse{}, err
+		}
+	}
+
+	link, err = s.shareLinkRepo.Create(link)
+	if err != nil {
+		return dto.ShareLinkResponse{}, err
+	}
+	return shareLinkResponse(link), nil
+}
+
+// GetByFile lists the links to a file the user manages, revoked and expired
+// ones included.
+func (s *shareService) GetByFile(ctx context.Context, userID, fileID string) ([]dto.ShareLinkResponse, error) {
+	if _, err := getFile(s.auth, s.fileRepo, userID, fileID, constants.ENUM_PERMISSION_MANAGE); err != nil {
+		return nil, err
+	}
+
+	links, err := s.shareLinkRepo.GetByFile(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []dto.ShareLinkResponse{}
+	for _, link := range links {
+		res = append(res, shareLinkResponse(link))
+	}
+	return res, nil
+}
+
+// Revoke cuts access through a link to a file the user manages for good.
+func (s *shareService) Revoke(ctx context.Context, userID, linkID string) (dto.ShareLinkResponse, error) {
+	if _, err := uuid.Parse(linkID); err != nil {
+		return dto.ShareLinkResponse{}, dto.ErrShareLinkNotFound
+	}
+
+	link, err := s.shareLinkRepo.Get(linkID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dto.ShareLinkResponse{}, dto.ErrShareLinkNotFound
+		}
+		return dto.ShareLinkResponse{}, err
+	}
+
+	if _, err := getFile(s.auth, s.fileRepo, userID, link.FileID.String(), constants.ENUM_PERMISSION_MANAGE); err != nil {
+		if err == dto.ErrUnauthorizedFileAccess {
+			return dto.ShareLinkResponse{}, dto.ErrUnauthorizedShareLinkAccess
+		}
+		return dto.ShareLinkResponse{}, err
+	}
+
+	link, err = s.shareLinkRepo.Revoke(linkID)
+	if err != nil {
+		return dto.ShareLinkResponse{}, err
+	}
+	return shareLinkResponse(link), nil
+}
+
+// Open opens the file of a link, checking the password of protected links.
+// Only requests that download the file count towards the link's limit, so
+// checking its headers does not use it up.
+func (s *shareService) Open(ctx context.Context, token, password string, download bool) (dto.FileResponse, error) {
+	link, err := s.shareLinkRepo.GetByToken(token)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dto.FileResponse{}, dto.ErrShareLinkNotFound
+		}
+		return dto.FileResponse{}, err
+	}
+
+	if link.RevokedAt != nil {
+		return dto.FileResponse{}, dto.ErrShareLinkRevoked
+	}
+	if link.ExpiresAt != nil && !time.Now().Before(*link.ExpiresAt) {
+		return dto.FileResponse{}, dto.ErrShareLinkExpired
+	}
+	if link.Password != "" {
+		if password == "" {
+			return dto.FileResponse{}, dto.ErrShareLinkPasswordRequired
+		}
+		if ok, _ := utils.CheckPassword(link.Password, []byte(password)); !ok {
+			return dto.FileResponse{}, dto.ErrInvalidShareLinkPassword
+		}
+	}
+	if link.MaxDownloads != nil && link.Downloads >= *link.MaxDownloads {
+		return dto.FileResponse{}, dto.ErrShareLinkDownloadLimit
+	}
+
+	// files in the trash are not shared anymore
+	file, err := s.fileRepo.Get(link.FileID.String())
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dto.FileResponse{}, dto.ErrShareLinkNotFound
+		}
+		return dto.FileResponse{}, err
+	}
+
+	if download {
+		ok, err := s.shareLinkRepo.CountDownload(link.ID.String())
+		if err != nil {
+			return dto.FileResponse{}, err
+		}
+		if !ok {
+			return dto.FileResponse{}, dto.ErrShareLinkDownloadLimit
+		}
+	}
+
+	content, err := s.fileRepo.OpenFile(ctx, file)
+	if err != nil {
+		return dto.FileResponse{}, err
+	}
+
+	return dto.FileResponse{
+		ID:        file.ID.String(),
+		Filename:  file.Filename,
+		Size:      file.Size,
+		MimeType:  file.MimeType,
+		Checksum:  file.Checksum,
+		Shareable: file.Shareable,
+		FolderID:  folderIDResponse(file.FolderID),
+		Version:   file.Version,
+		ModTime:   modTime(file),
+		Content:   content,
+	}, nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// sharePreview decides how a file is previewed on its share page. Browsers
+// show images and PDFs themselves, the files previewKind renders are shown
+// as plain text.
+func sharePreview(filename, mimeType string) string {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return constants.ENUM_SHARE_PREVIEW_IMAGE
+	case mimeType == "application/pdf":
+		return constants.ENUM_SHARE_PREVIEW_PDF
+	}
+
+	if kind, _ := previewKind(filename, mimeType); kind != "" {
+		return constants.ENUM_SHARE_PREVIEW_TEXT
+	}
+	return ""
+}
+
+// GetSharePage returns what the share page of a file shows, with the same
+// visibility as downloading it.
+func (s *fileService) GetSharePage(ctx context.Context, userID, fileID string) (dto.SharePageResponse, error) {
+	file, err := s.fileRepo.Get(fileID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dto.SharePageResponse{}, dto.ErrFileNotFound
+		}
+		return dto.SharePageResponse{}, err
+	}
+
+	if err := s.checkView(file, userID); err != nil {
+		return dto.SharePageResponse{}, err
+	}
+
+	owner, err := s.userRepo.GetUserById(file.UserID.String())
+	if err != nil {
+		return dto.SharePageResponse{}, err
+	}
+
+	page := dto.SharePageResponse{
+		File: dto.FileResponse{
+			ID:        file.ID.String(),
+			Filename:  file.Filename,
+			Size:      file.Size,
+			MimeType:  file.MimeType,
+			Checksum:  file.Checksum,
+			Shareable: file.Shareable,
+			FolderID:  folderIDResponse(file.FolderID),
+			TeamID:    teamIDResponse(file.TeamID),
+			Version:   file.Version,
+			ModTime:   modTime(file),
+		},
+		Owner:   owner.Username,
+		Preview: sharePreview(file.Filename, file.MimeType),
+	}
+
+	// only text previews are rendered in the page, the browser loads the
+	// others from the file's URL
+	if page.Preview == constants.ENUM_SHARE_PREVIEW_TEXT {
+		content, err := s.fileRepo.OpenFile(ctx, file)
+		if err != nil {
+			return dto.SharePageResponse{}, err
+		}
+		defer content.Close()
+
+		data, truncated, err := readPreview(content, constants.MAX_SHARE_PAGE_TEXT_SIZE)
+		if err != nil {
+			return dto.SharePageResponse{}, err
+		}
+		page.Text = strings.ToValidUTF8(string(data), "�")
+		page.Truncated = truncated
+	}
+
+	return page, nil
+}
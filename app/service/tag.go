@@ -0,0 +1,166 @@
+package service
+
+import (
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/entity"
+	"FP-DevOps/repository"
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type (
+	TagService interface {
+		GetTags(context.Context, string) ([]dto.TagResponse, error)
+		AddToFile(context.Context, string, string, dto.AddTagsRequest) (dto.FileTagsResponse, error)
+		RemoveFromFile(context.Context, string, string, string) (dto.FileTagsResponse, error)
+		Delete(context.Context, string, string) error
+	}
+
+	tagService struct {
+		tagRepo  repository.TagRepository
+		fileRepo repository.FileRepository
+		auth     Authorizer
+	}
+)
+
+var tagNamePattern = regexp.MustCompile(`^[\p{L}\p{N} _.-]+$`)
+
+func NewTagService(tr repository.TagRepository, fileRepo repository.FileRepository, auth Authorizer) TagService {
+	return &tagService{
+		tagRepo:  tr,
+		fileRepo: fileRepo,
+		auth:     auth,
+	}
+}
+
+// normalizeTagName trims and lowercases a tag name, so "Invoices" and
+// "invoices " are the same tag.
+func normalizeTagName(name string) (string, error) {
+	name = strings.ToLower(strings.Join(strings.Fields(name), " "))
+	if len([]rune(name)) > constants.MAX_TAG_LENGTH || !tagNamePattern.MatchString(name) {
+		return "", dto.ErrInvalidTagName
+	}
+	return name, nil
+}
+
+func (s *tagService) fileTags(userID, fileID string) (dto.FileTagsResponse, error) {
+	tags, err := s.tagRepo.GetFileTags(fileID, userID)
+	if err != nil {
+		return dto.FileTagsResponse{}, err
+	}
+
+	res := dto.FileTagsResponse{FileID: fileID, Tags: []string{}}
+	for _, tag := range tags {
+		res.Tags = append(res.Tags, tag.Name)
+	}
+	return res, nil
+}
+
+func (s *tagService) GetTags(ctx context.Context, userID string) ([]dto.TagResponse, error) {
+	return s.tagRepo.GetWithCounts(userID)
+}
+
+// AddToFile tags a file the user can see, creating the tags the user does not
+// have yet. Tags are the user's own, so viewing the file is enough to tag it.
+// Tags the file already has are left as they are.
+func (s *tagService) AddToFile(ctx context.Context, userID, fileID string, req dto.AddTagsRequest) (dto.FileTagsResponse, error) {
+	file, err := getFile(s.auth, s.fileRepo, userID, fileID, constants.ENUM_PERMISSION_VIEW)
+	if err != nil {
+		return dto.FileTagsResponse{}, err
+	}
+
+	names := map[string]bool{}
+	for _, name := range req.Tags {
+		name, err := normalizeTagName(name)
+		if err != nil {
+			return dto.FileTagsResponse{}, err
+		}
+		names[name] = true
+	}
+
+	current, err := s.tagRepo.GetFileTags(fileID, userID)
+	if err != nil {
+		return dto.FileTagsResponse{}, err
+	}
+	for _, tag := range current {
+		delete(names, tag.Name)
+	}
+	if len(current)+len(names) > constants.MAX_TAGS_PER_FILE {
+		return dto.FileTagsResponse{}, dto.ErrTooManyTags
+	}
+
+	var tags []entity.Tag
+	for name := range names {
+		tag, err := s.tagRepo.FirstOrCreate(userID, name)
+		if err != nil {
+			return dto.FileTagsResponse{}, err
+		}
+		tags = append(tags, tag)
+	}
+
+	if len(tags) > 0 {
+		if err := s.tagRepo.AddToFile(file, tags); err != nil {
+			return dto.FileTagsResponse{}, err
+		}
+	}
+	return s.fileTags(userID, fileID)
+}
+
+// RemoveFromFile takes the tag of the user with the given name off a file, the
+// tag itself is kept for the user's other files.
+func (s *tagService) RemoveFromFile(ctx context.Context, userID, fileID, name string) (dto.FileTagsResponse, error) {
+	file, err := getFile(s.auth, s.fileRepo, userID, fileID, constants.ENUM_PERMISSION_VIEW)
+	if err != nil {
+		return dto.FileTagsResponse{}, err
+	}
+
+	name, err = normalizeTagName(name)
+	if err != nil {
+		return dto.FileTagsResponse{}, dto.ErrTagNotFound
+	}
+
+	tags, err := s.tagRepo.GetFileTags(fileID, userID)
+	if err != nil {
+		return dto.FileTagsResponse{}, err
+	}
+
+	var tagID string
+	for _, tag := range tags {
+		if tag.Name == name {
+			tagID = tag.ID.String()
+		}
+	}
+	if tagID == "" {
+		return dto.FileTagsResponse{}, dto.ErrTagNotFound
+	}
+
+	if err := s.tagRepo.RemoveFromFile(file, tagID); err != nil {
+		return dto.FileTagsResponse{}, err
+	}
+	return s.fileTags(userID, fileID)
+}
+
+// Delete deletes a tag of the user and takes it off all of their files.
+func (s *tagService) Delete(ctx context.Context, userID, tagID string) error {
+	if _, err := uuid.Parse(tagID); err != nil {
+		return dto.ErrTagNotFound
+	}
+
+	tag, err := s.tagRepo.Get(tagID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dto.ErrTagNotFound
+		}
+		return err
+	}
+
+	if tag.UserID.String() != userID {
+		return dto.ErrUnauthorizedTagAccess
+	}
+	return s.tagRepo.Delete(tagID)
+}
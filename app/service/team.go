@@ -0,0 +1,337 @@
+package service
+
+import (
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/entity"
+	"FP-DevOps/repository"
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type (
+	// TeamService manages teams, their members and the invitations to join
+	// them. What members may do with the team's files is up to the
+	// Authorizer.
+	TeamService interface {
+		Create(context.Context, string, dto.CreateTeamRequest) (dto.TeamResponse, error)
+		GetTeams(context.Context, string) ([]dto.TeamResponse, error)
+		GetTeam(context.Context, string, string) (dto.TeamDetailResponse, error)
+		Delete(context.Context, string, string) error
+		Invite(context.Context, string, string, dto.InviteTeamMemberRequest) (dto.TeamInvitationResponse, error)
+		GetInvitations(context.Context, string) ([]dto.TeamInvitationResponse, error)
+		AcceptInvitation(context.Context, string, string) (dto.TeamResponse, error)
+		DeclineInvitation(context.Context, string, string) error
+		UpdateMember(context.Context, string, string, string, dto.UpdateTeamMemberRequest) (dto.TeamMemberResponse, error)
+		RemoveMember(context.Context, string, string, string) error
+	}
+
+	teamService struct {
+		teamRepo repository.TeamRepository
+		userRepo repository.UserRepository
+		auth     Authorizer
+	}
+)
+
+func NewTeamService(tr repository.TeamRepository, userRepo repository.UserRepository, auth Authorizer) TeamService {
+	return &teamService{
+		teamRepo: tr,
+		userRepo: userRepo,
+		auth:     auth,
+	}
+}
+
+func teamInvitationResponse(invitation entity.TeamInvitation) dto.TeamInvitationResponse {
+	return dto.TeamInvitationResponse{
+		ID:        invitation.ID.String(),
+		TeamID:    invitation.TeamID.String(),
+		TeamName:  invitation.Team.Name,
+		Username:  invitation.User.Username,
+		Role:      invitation.Role,
+		InvitedBy: invitation.InvitedBy.Username,
+	}
+}
+
+// checkRole fails unless role is a role members can have.
+func checkRole(role string) error {
+	if _, ok := teamRoleLevels[role]; !ok {
+		return dto.ErrInvalidTeamRole
+	}
+	return nil
+}
+
+// Create creates a team with the user as its owner.
+func (s *teamService) Create(ctx context.Context, userID string, req dto.CreateTeamRequest) (dto.TeamResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return dto.TeamResponse{}, dto.ErrTeamNameRequired
+	}
+
+	team, err := s.teamRepo.Create(entity.Team{Name: name}, entity.TeamMember{
+		UserID: uuid.MustParse(userID),
+		Role:   constants.ENUM_TEAM_ROLE_OWNER,
+	})
+	if err != nil {
+		return dto.TeamResponse{}, err
+	}
+
+	return dto.TeamResponse{
+		ID:   team.ID.String(),
+		Name: team.Name,
+		Role: constants.ENUM_TEAM_ROLE_OWNER,
+	}, nil
+}
+
+// GetTeams lists the teams the user is a member of.
+func (s *teamService) GetTeams(ctx context.Context, userID string) ([]dto.TeamResponse, error) {
+	members, err := s.teamRepo.GetByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []dto.TeamResponse{}
+	for _, member := range members {
+		res = append(res, dto.TeamResponse{
+			ID:   member.TeamID.String(),
+			Name: member.Team.Name,
+			Role: member.Role,
+		})
+	}
+	return res, nil
+}
+
+func (s *teamService) GetTeam(ctx context.Context, userID, teamID string) (dto.TeamDetailResponse, error) {
+	role, err := s.auth.CheckTeam(teamID, userID, constants.ENUM_TEAM_ROLE_VIEWER)
+	if err != nil {
+		return dto.TeamDetailResponse{}, err
+	}
+
+	team, err := s.teamRepo.Get(teamID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dto.TeamDetailResponse{}, dto.ErrTeamNotFound
+		}
+		return dto.TeamDetailResponse{}, err
+	}
+
+	members, err := s.teamRepo.GetMembers(teamID)
+	if err != nil {
+		return dto.TeamDetailResponse{}, err
+	}
+
+	res := dto.TeamDetailResponse{
+		TeamResponse: dto.TeamResponse{
+			ID:   team.ID.String(),
+			Name: team.Name,
+			Role: role,
+		},
+		Members: []dto.TeamMemberResponse{},
+	}
+	for _, member := range members {
+		res.Members = append(res.Members, dto.TeamMemberResponse{
+			UserID:   member.UserID.String(),
+			Username: member.User.Username,
+			Role:     member.Role,
+		})
+	}
+	return res, nil
+}
+
+// Delete deletes a team for its owners. Only teams without files and folders
+// can be deleted, so nothing is lost with them.
+func (s *teamService) Delete(ctx context.Context, userID, teamID string) error {
+	if _, err := s.auth.CheckTeam(teamID, userID, constants.ENUM_TEAM_ROLE_OWNER); err != nil {
+		return err
+	}
+
+	hasContent, err := s.teamRepo.HasContent(teamID)
+	if err != nil {
+		return err
+	}
+	if hasContent {
+		return dto.ErrTeamNotEmpty
+	}
+
+	return s.teamRepo.Delete(teamID)
+}
+
+// Invite invites a user to the team by username. Admins invite with a role up
+// to their own, and inviting someone again replaces the pending invitation.
+func (s *teamService) Invite(ctx context.Context, userID, teamID string, req dto.InviteTeamMemberRequest) (dto.TeamInvitationResponse, error) {
+	role, err := s.auth.CheckTeam(teamID, userID, constants.ENUM_TEAM_ROLE_ADMIN)
+	if err != nil {
+		return dto.TeamInvitationResponse{}, err
+	}
+
+	if err := checkRole(req.Role); err != nil {
+		return dto.TeamInvitationResponse{}, err
+	}
+	if teamRoleLevels[req.Role] > teamRoleLevels[role] {
+		return dto.TeamInvitationResponse{}, dto.ErrUnauthorizedTeamAccess
+	}
+
+	invitee, err := s.userRepo.GetUserByUsername(req.Username)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dto.TeamInvitationResponse{}, dto.ErrInviteeNotFound
+		}
+		return dto.TeamInvitationResponse{}, err
+	}
+
+	current, err := s.teamRepo.GetRole(teamID, invitee.ID.String())
+	if err != nil {
+		return dto.TeamInvitationResponse{}, err
+	}
+	if current != "" {
+		return dto.TeamInvitationResponse{}, dto.ErrAlreadyTeamMember
+	}
+
+	invitation, err := s.teamRepo.UpsertInvitation(entity.TeamInvitation{
+		TeamID:      uuid.MustParse(teamID),
+		UserID:      invitee.ID,
+		Role:        req.Role,
+		InvitedByID: uuid.MustParse(userID),
+	})
+	if err != nil {
+		return dto.TeamInvitationResponse{}, err
+	}
+
+	invitation, err = s.teamRepo.GetInvitation(invitation.ID.String())
+	if err != nil {
+		return dto.TeamInvitationResponse{}, err
+	}
+	return teamInvitationResponse(invitation), nil
+}
+
+// GetInvitations lists the pending invitations of the user.
+func (s *teamService) GetInvitations(ctx context.Context, userID string) ([]dto.TeamInvitationResponse, error) {
+	invitations, err := s.teamRepo.GetInvitationsByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []dto.TeamInvitationResponse{}
+	for _, invitation := range invitations {
+		res = append(res, teamInvitationResponse(invitation))
+	}
+	return res, nil
+}
+
+// getInvitation returns the invitation if it is addressed to the user.
+func (s *teamService) getInvitation(userID, invitationID string) (entity.TeamInvitation, error) {
+	if _, err := uuid.Parse(invitationID); err != nil {
+		return entity.TeamInvitation{}, dto.ErrInvitationNotFound
+	}
+
+	invitation, err := s.teamRepo.GetInvitation(invitationID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entity.TeamInvitation{}, dto.ErrInvitationNotFound
+		}
+		return entity.TeamInvitation{}, err
+	}
+
+	// invitations of other users are not given away
+	if invitation.UserID.String() != userID {
+		return entity.TeamInvitation{}, dto.ErrInvitationNotFound
+	}
+	return invitation, nil
+}
+
+// AcceptInvitation makes the user a member of the team they were invited to.
+func (s *teamService) AcceptInvitation(ctx context.Context, userID, invitationID string) (dto.TeamResponse, error) {
+	invitation, err := s.getInvitation(userID, invitationID)
+	if err != nil {
+		return dto.TeamResponse{}, err
+	}
+
+	if err := s.teamRepo.AcceptInvitation(invitation); err != nil {
+		return dto.TeamResponse{}, err
+	}
+
+	return dto.TeamResponse{
+		ID:   invitation.TeamID.String(),
+		Name: invitation.Team.Name,
+		Role: invitation.Role,
+	}, nil
+}
+
+func (s *teamService) DeclineInvitation(ctx context.Context, userID, invitationID string) error {
+	if _, err := s.getInvitation(userID, invitationID); err != nil {
+		return err
+	}
+	return s.teamRepo.DeleteInvitation(invitationID)
+}
+
+// checkMemberChange fails unless the user may change the role of the member
+// or remove them. Admins manage the members below owners, owners everyone.
+func (s *teamService) checkMemberChange(userID, teamID, memberID string) (string, error) {
+	role, err := s.auth.CheckTeam(teamID, userID, constants.ENUM_TEAM_ROLE_ADMIN)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := uuid.Parse(memberID); err != nil {
+		return "", dto.ErrTeamMemberNotFound
+	}
+	current, err := s.teamRepo.GetRole(teamID, memberID)
+	if err != nil {
+		return "", err
+	}
+	if current == "" {
+		return "", dto.ErrTeamMemberNotFound
+	}
+
+	if current == constants.ENUM_TEAM_ROLE_OWNER && role != constants.ENUM_TEAM_ROLE_OWNER {
+		return "", dto.ErrUnauthorizedTeamAccess
+	}
+	return current, nil
+}
+
+// UpdateMember changes the role of a member, no one can give a role above
+// their own. The last owner of a team cannot step down.
+func (s *teamService) UpdateMember(ctx context.Context, userID, teamID, memberID string, req dto.UpdateTeamMemberRequest) (dto.TeamMemberResponse, error) {
+	if _, err := s.checkMemberChange(userID, teamID, memberID); err != nil {
+		return dto.TeamMemberResponse{}, err
+	}
+
+	if err := checkRole(req.Role); err != nil {
+		return dto.TeamMemberResponse{}, err
+	}
+	if req.Role == constants.ENUM_TEAM_ROLE_OWNER {
+		if _, err := s.auth.CheckTeam(teamID, userID, constants.ENUM_TEAM_ROLE_OWNER); err != nil {
+			return dto.TeamMemberResponse{}, err
+		}
+	}
+
+	if err := s.teamRepo.SetRole(teamID, memberID, req.Role); err != nil {
+		return dto.TeamMemberResponse{}, err
+	}
+
+	member, err := s.userRepo.GetUserById(memberID)
+	if err != nil {
+		return dto.TeamMemberResponse{}, err
+	}
+	return dto.TeamMemberResponse{
+		UserID:   memberID,
+		Username: member.Username,
+		Role:     req.Role,
+	}, nil
+}
+
+// RemoveMember removes a member from the team, members may also leave on
+// their own. The files they uploaded stay with the team.
+func (s *teamService) RemoveMember(ctx context.Context, userID, teamID, memberID string) error {
+	if memberID == userID {
+		if _, err := s.auth.CheckTeam(teamID, userID, constants.ENUM_TEAM_ROLE_VIEWER); err != nil {
+			return err
+		}
+	} else if _, err := s.checkMemberChange(userID, teamID, memberID); err != nil {
+		return err
+	}
+
+	return s.teamRepo.RemoveMember(teamID, memberID)
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/entity"
+	"FP-DevOps/repository"
+	"bytes"
+	"context"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+type (
+	// ThumbnailService generates thumbnails of image files in the background.
+	// Uploads only queue their content, the thumbnails are written by the
+	// workers started with Run.
+	ThumbnailService interface {
+		Enqueue(entity.File)
+		Run(context.Context)
+		Open(context.Context, entity.File, int) (io.ReadSeekCloser, string, error)
+	}
+
+	thumbnailService struct {
+		thumbnailRepo repository.ThumbnailRepository
+		fileRepo      repository.FileRepository
+		queue         chan entity.File
+		// pending holds the checksums queued or being generated, failed the
+		// ones whose content could not be decoded as an image lately
+		pending sync.Map
+		failed  *failedThumbnails
+	}
+
+	// failedThumbnails remembers the checksums thumbnails failed for until
+	// they expire, so a storage error is retried eventually and bad images
+	// do not pile up in memory.
+	failedThumbnails struct {
+		mu      sync.Mutex
+		expires map[string]time.Time
+		ttl     time.Duration
+		max     int
+	}
+)
+
+func newFailedThumbnails(ttl time.Duration, max int) *failedThumbnails {
+	return &failedThumbnails{
+		expires: make(map[string]time.Time),
+		ttl:     ttl,
+		max:     max,
+	}
+}
+
+func (f *failedThumbnails) Add(checksum string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	if len(f.expires) >= f.max {
+		for key, expires := range f.expires {
+			if now.After(expires) {
+				delete(f.expires, key)
+			}
+		}
+	}
+	// still full of recent failures, the one expiring first makes room
+	if len(f.expires) >= f.max {
+		var oldest string
+		for key, expires := range f.expires {
+			if oldest == "" || expires.Before(f.expires[oldest]) {
+				oldest = key
+			}
+		}
+		delete(f.expires, oldest)
+	}
+	f.expires[checksum] = now.Add(f.ttl)
+}
+
+func (f *failedThumbnails) Has(checksum string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	expires, ok := f.expires[checksum]
+	if ok && time.Now().After(expires) {
+		delete(f.expires, checksum)
+		return false
+	}
+	return ok
+}
+
+var thumbnailSizes = map[int]bool{
+	constants.THUMBNAIL_SIZE_SMALL:  true,
+	constants.THUMBNAIL_SIZE_MEDIUM: true,
+	constants.THUMBNAIL_SIZE_LARGE:  true,
+}
+
+var thumbnailMimeTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+func NewThumbnailService(thumbnailRepo repository.ThumbnailRepository, fileRepo repository.FileRepository) ThumbnailService {
+	return &thumbnailService{
+		thumbnailRepo: thumbnailRepo,
+		fileRepo:      fileRepo,
+		queue:         make(chan entity.File, constants.THUMBNAIL_QUEUE_SIZE),
+		failed:        newFailedThumbnails(constants.THUMBNAIL_FAILURE_TTL_IN_MINUTES*time.Minute, constants.MAX_THUMBNAIL_FAILURES),
+	}
+}
+
+// hasThumbnail tells whether thumbnails can be generated for the file. Files
+// stored before checksums were computed have nothing to key them by.
+func hasThumbnail(file entity.File) bool {
+	return file.Checksum != "" && thumbnailMimeTypes[file.MimeType]
+}
+
+// thumbnailFormat is the extension and MIME type thumbnails of a file are
+// stored with. Photos stay JPEG, everything else becomes PNG to keep
+// transparency.
+func thumbnailFormat(mimeType string) (string, string) {
+	if mimeType == "image/jpeg" {
+		return "jpg", "image/jpeg"
+	}
+	return "png", "image/png"
+}
+
+// Enqueue queues the generation of the file's thumbnails without waiting for
+// it. When the queue is full the file is skipped, its thumbnails are queued
+// again the first time they are requested.
+func (s *thumbnailService) Enqueue(file entity.File) {
+	if !hasThumbnail(file) {
+		return
+	}
+	if _, queued := s.pending.LoadOrStore(file.Checksum, true); queued {
+		return
+	}
+
+	select {
+	case s.queue <- file:
+	default:
+		s.pending.Delete(file.Checksum)
+		log.Printf("thumbnail queue is full, skipping file %s", file.ID)
+	}
+}
+
+// Run generates queued thumbnails until ctx is done.
+func (s *thumbnailService) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < constants.THUMBNAIL_WORKERS; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case file := <-s.queue:
+					if err := s.generate(ctx, file); err != nil {
+						log.Printf("error generating thumbnails of file %s: %v", file.ID, err)
+					}
+					s.pending.Delete(file.Checksum)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// generate writes the thumbnails of the file that do not exist yet.
+func (s *thumbnailService) generate(ctx context.Context, file entity.File) error {
+	ext, _ := thumbnailFormat(file.MimeType)
+
+	var sizes []int
+	for size := range thumbnailSizes {
+		exists, err := s.thumbnailRepo.Exists(ctx, file.Checksum, size, ext)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			sizes = append(sizes, size)
+		}
+	}
+	if len(sizes) == 0 {
+		return nil
+	}
+
+	content, err := s.fileRepo.OpenFile(ctx, file)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	// the dimensions are checked before decoding, so a small file claiming a
+	// huge image cannot make the server allocate its pixels
+	config, _, err := image.DecodeConfig(content)
+	if err != nil || config.Width*config.Height > constants.MAX_THUMBNAIL_SOURCE_PIXELS {
+		s.failed.Add(file.Checksum)
+		return err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	img, _, err := image.Decode(content)
+	if err != nil {
+		s.failed.Add(file.Checksum)
+		return err
+	}
+
+	for _, size := range sizes {
+		var buf bytes.Buffer
+		thumbnail := resizeImage(img, size)
+		if ext == "jpg" {
+			err = jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 85})
+		} else {
+			err = png.Encode(&buf, thumbnail)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := s.thumbnailRepo.Write(ctx, file.Checksum, size, ext, &buf, int64(buf.Len())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resizeImage scales img down to fit in a size x size square, keeping its
+// aspect ratio. Images that already fit are not scaled up.
+func resizeImage(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return img
+	}
+
+	if width >= height {
+		width, height = size, height*size/width
+	} else {
+		width, height = width*size/height, size
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), img, bounds, draw.Src, nil)
+	return thumbnail
+}
+
+// Open returns a thumbnail of the file with its MIME type. Thumbnails that do
+// not exist yet are queued and reported as pending.
+func (s *thumbnailService) Open(ctx context.Context, file entity.File, size int) (io.ReadSeekCloser, string, error) {
+	if !thumbnailSizes[size] {
+		return nil, "", dto.ErrInvalidThumbnailSize
+	}
+	if !hasThumbnail(file) {
+		return nil, "", dto.ErrThumbnailNotFound
+	}
+	if s.failed.Has(file.Checksum) {
+		return nil, "", dto.ErrThumbnailNotFound
+	}
+
+	ext, mimeType := thumbnailFormat(file.MimeType)
+	thumbnail, err := s.thumbnailRepo.Open(ctx, file.Checksum, size, ext)
+	if err != nil {
+		if err == dto.ErrThumbnailNotFound {
+			s.Enqueue(file)
+			return nil, "", dto.ErrThumbnailPending
+		}
+		return nil, "", err
+	}
+	return thumbnail, mimeType, nil
+}
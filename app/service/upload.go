@@ -0,0 +1,266 @@
+package service
+
+import (
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/entity"
+	"FP-DevOps/repository"
+	"context"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type (
+	UploadService interface {
+		Create(context.Context, string, dto.CreateUploadRequest) (dto.UploadResponse, error)
+		Get(context.Context, string, string) (dto.UploadResponse, error)
+		Append(context.Context, string, string, dto.AppendUploadRequest) (dto.UploadResponse, error)
+		PurgeExpired(context.Context) error
+		MaxSize() int64
+	}
+
+	uploadService struct {
+		uploadRepo    repository.UploadRepository
+		fileService   FileService
+		maxUploadSize int64
+		expiration    time.Duration
+	}
+)
+
+func NewUploadService(ur repository.UploadRepository, fs FileService) UploadService {
+	return &uploadService{
+		uploadRepo:    ur,
+		fileService:   fs,
+		maxUploadSize: maxUploadSize(),
+		expiration:    uploadExpiration(),
+	}
+}
+
+func uploadExpiration() time.Duration {
+	hours, err := strconv.ParseInt(os.Getenv("UPLOAD_EXPIRATION_HOURS"), 10, 64)
+	if err != nil || hours <= 0 {
+		hours = constants.DEFAULT_UPLOAD_EXPIRATION_HOURS
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+func uploadResponse(upload entity.Upload) dto.UploadResponse {
+	res := dto.UploadResponse{
+		ID:        upload.ID.String(),
+		Filename:  upload.Filename,
+		Length:    upload.Length,
+		Offset:    upload.Offset,
+		ExpiresAt: upload.ExpiresAt,
+	}
+	if upload.FileID != nil {
+		res.FileID = upload.FileID.String()
+	}
+	return res
+}
+
+func (s *uploadService) MaxSize() int64 {
+	return s.maxUploadSize
+}
+
+func (s *uploadService) Create(ctx context.Context, userID string, req dto.CreateUploadRequest) (dto.UploadResponse, error) {
+	if req.Length > s.maxUploadSize {
+		return dto.UploadResponse{}, dto.ErrFileSizeExceeded
+	}
+	if err := s.fileService.CheckUpload(ctx, userID, req.FolderID, req.Length); err != nil {
+		return dto.UploadResponse{}, err
+	}
+
+	// the folder has been checked, anything but a folder ID is the root
+	var folderID *uuid.UUID
+	if id, err := uuid.Parse(req.FolderID); err == nil {
+		folderID = &id
+	}
+
+	upload, err := s.uploadRepo.Create(entity.Upload{
+		ID:        uuid.New(),
+		Filename:  req.Filename,
+		FolderID:  folderID,
+		Length:    req.Length,
+		ExpiresAt: time.Now().Add(s.expiration),
+		UserID:    uuid.MustParse(userID),
+	})
+	if err != nil {
+		return dto.UploadResponse{}, err
+	}
+
+	// an empty upload is complete as soon as it is created
+	if upload.Length == 0 {
+		if upload, err = s.finalize(ctx, upload); err != nil {
+			return dto.UploadResponse{}, err
+		}
+	}
+
+	return uploadResponse(upload), nil
+}
+
+func (s *uploadService) get(userID, uploadID string) (entity.Upload, error) {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return entity.Upload{}, dto.ErrUploadNotFound
+	}
+
+	upload, err := s.uploadRepo.Get(uploadID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entity.Upload{}, dto.ErrUploadNotFound
+		}
+		return entity.Upload{}, err
+	}
+
+	if upload.UserID.String() != userID {
+		return entity.Upload{}, dto.ErrUnauthorizedUploadAccess
+	}
+
+	if time.Now().After(upload.ExpiresAt) {
+		return entity.Upload{}, dto.ErrUploadExpired
+	}
+
+	return upload, nil
+}
+
+func (s *uploadService) Get(ctx context.Context, userID, uploadID string) (dto.UploadResponse, error) {
+	upload, err := s.get(userID, uploadID)
+	if err != nil {
+		return dto.UploadResponse{}, err
+	}
+
+	return uploadResponse(upload), nil
+}
+
+func (s *uploadService) Append(ctx context.Context, userID, uploadID string, req dto.AppendUploadRequest) (dto.UploadResponse, error) {
+	upload, err := s.get(userID, uploadID)
+	if err != nil {
+		return dto.UploadResponse{}, err
+	}
+
+	if req.Offset != upload.Offset {
+		return dto.UploadResponse{}, dto.ErrUploadOffsetMismatch
+	}
+
+	remaining := upload.Length - upload.Offset
+	if req.Size > remaining {
+		return dto.UploadResponse{}, dto.ErrUploadLengthExceeded
+	}
+
+	if remaining > 0 {
+		if upload, err = s.appendChunk(ctx, upload, req.Content, remaining); err != nil {
+			return dto.UploadResponse{}, err
+		}
+	}
+
+	// finalizing is retried by any PATCH arriving after the last chunk in case
+	// it failed the first time around
+	if upload.Offset == upload.Length && upload.FileID == nil {
+		if upload, err = s.finalize(ctx, upload); err != nil {
+			return dto.UploadResponse{}, err
+		}
+	}
+
+	return uploadResponse(upload), nil
+}
+
+// appendChunk spools the request body before storing it, so the bytes received
+// before a dropped connection are kept and the client resumes from there.
+func (s *uploadService) appendChunk(ctx context.Context, upload entity.Upload, body io.Reader, remaining int64) (entity.Upload, error) {
+	tmp, err := os.CreateTemp("", "upload-chunk-*")
+	if err != nil {
+		return entity.Upload{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	received, readErr := io.Copy(tmp, io.LimitReader(body, remaining+1))
+	if received > remaining {
+		return entity.Upload{}, dto.ErrUploadLengthExceeded
+	}
+
+	if received > 0 {
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return entity.Upload{}, err
+		}
+
+		upload, err = s.uploadRepo.AppendChunk(ctx, upload.ID.String(), upload.Offset, tmp, received, time.Now().Add(s.expiration))
+		if err != nil {
+			return entity.Upload{}, err
+		}
+	}
+
+	if readErr != nil {
+		return entity.Upload{}, readErr
+	}
+	return upload, nil
+}
+
+func (s *uploadService) finalize(ctx context.Context, upload entity.Upload) (entity.Upload, error) {
+	// the file is created with the upload's ID, so a file created by an earlier
+	// attempt that failed to record it is picked up instead of duplicated
+	fileID := upload.ID
+	exists, err := s.uploadRepo.HasFile(fileID.String())
+	if err != nil {
+		return entity.Upload{}, err
+	}
+
+	if !exists {
+		content, err := s.uploadRepo.ReadChunks(ctx, upload.ID.String())
+		if err != nil {
+			return entity.Upload{}, err
+		}
+		defer content.Close()
+
+		req := dto.CreateFileRequest{
+			ID:       fileID.String(),
+			Filename: upload.Filename,
+			Content:  content,
+		}
+		if upload.FolderID != nil {
+			req.FolderID = upload.FolderID.String()
+		}
+
+		_, err = s.fileService.Create(ctx, upload.UserID.String(), req)
+		if err != nil {
+			return entity.Upload{}, err
+		}
+	}
+
+	upload.FileID = &fileID
+	if upload, err = s.uploadRepo.Update(upload); err != nil {
+		return entity.Upload{}, err
+	}
+
+	if err := s.uploadRepo.DeleteChunks(ctx, upload.ID.String()); err != nil {
+		return entity.Upload{}, err
+	}
+
+	return upload, nil
+}
+
+func (s *uploadService) PurgeExpired(ctx context.Context) error {
+	uploads, err := s.uploadRepo.GetExpired(time.Now())
+	if err != nil {
+		return err
+	}
+
+	// a failing upload is skipped so it does not hold back the others, it is
+	// retried on the next run
+	for _, upload := range uploads {
+		if err := s.uploadRepo.DeleteChunks(ctx, upload.ID.String()); err != nil {
+			log.Printf("error purging upload %s: %v", upload.ID, err)
+			continue
+		}
+		if err := s.uploadRepo.Delete(upload.ID.String()); err != nil {
+			log.Printf("error purging upload %s: %v", upload.ID, err)
+		}
+	}
+
+	return nil
+}
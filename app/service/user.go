@@ -71,8 +71,14 @@ func (s *userService) Me(ctx context.Context, userID string) (dto.UserResponse,
 		return dto.UserResponse{}, dto.ErrGetUserById
 	}
 
+	storage, err := storageUsage(s.userRepo, userID)
+	if err != nil {
+		return dto.UserResponse{}, err
+	}
+
 	return dto.UserResponse{
 		ID:       user.ID.String(),
 		Username: user.Username,
+		Storage:  &storage,
 	}, nil
 }
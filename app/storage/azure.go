@@ -0,0 +1,517 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	azureAPIVersion = "2021-08-06"
+	// azureBlockSize is the amount of content buffered per uploaded block
+	azureBlockSize = 4 << 20
+)
+
+type (
+	AzureBlobConfig struct {
+		// Endpoint is the blob service URL. When empty it defaults to
+		// "https://<account>.blob.core.windows.net". Azurite uses a path style
+		// endpoint such as "http://azurite:10000/devstoreaccount1".
+		Endpoint  string
+		Account   string
+		Container string
+		Prefix    string
+		// AccountKey enables shared key authentication.
+		AccountKey string
+		// SASToken is used instead of AccountKey when set.
+		SASToken string
+		// CreateContainer creates the container on startup if it does not exist.
+		CreateContainer bool
+	}
+
+	azureBackend struct {
+		config     AzureBlobConfig
+		endpoint   *url.URL
+		accountKey []byte
+		sasQuery   url.Values
+		client     *http.Client
+	}
+
+	azureError struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+
+	azureBlockList struct {
+		XMLName xml.Name `xml:"BlockList"`
+		Latest  []string `xml:"Latest"`
+	}
+
+	azureListResult struct {
+		Blobs struct {
+			Blob []struct {
+				Name       string `xml:"Name"`
+				Properties struct {
+					ContentLength int64  `xml:"Content-Length"`
+					LastModified  string `xml:"Last-Modified"`
+				} `xml:"Properties"`
+			} `xml:"Blob"`
+		} `xml:"Blobs"`
+		NextMarker string `xml:"NextMarker"`
+	}
+)
+
+func NewAzureBlobBackend(config AzureBlobConfig) (Backend, error) {
+	if config.Account == "" || config.Container == "" {
+		return nil, errors.New("azure: account and container are required")
+	}
+
+	if config.Endpoint == "" {
+		config.Endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", config.Account)
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("azure: invalid endpoint: %w", err)
+	}
+
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("azure: invalid endpoint %q", config.Endpoint)
+	}
+
+	backend := &azureBackend{
+		config:   config,
+		endpoint: endpoint,
+		client:   &http.Client{},
+	}
+	backend.config.Prefix = strings.Trim(config.Prefix, "/")
+
+	switch {
+	case config.SASToken != "":
+		backend.sasQuery, err = url.ParseQuery(strings.TrimPrefix(config.SASToken, "?"))
+		if err != nil {
+			return nil, fmt.Errorf("azure: invalid SAS token: %w", err)
+		}
+	case config.AccountKey != "":
+		backend.accountKey, err = base64.StdEncoding.DecodeString(config.AccountKey)
+		if err != nil {
+			return nil, fmt.Errorf("azure: invalid account key: %w", err)
+		}
+	default:
+		return nil, errors.New("azure: either an account key or a SAS token is required")
+	}
+
+	if config.CreateContainer {
+		if err := backend.createContainer(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	return backend, nil
+}
+
+func (b *azureBackend) blobName(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	if b.config.Prefix != "" {
+		key = b.config.Prefix + "/" + key
+	}
+	return key, nil
+}
+
+func (b *azureBackend) do(ctx context.Context, method, blob string, query url.Values, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	u := *b.endpoint
+	u.Path = b.endpoint.Path + "/" + b.config.Container
+	if blob != "" {
+		u.Path += "/" + blob
+	}
+
+	if query == nil {
+		query = url.Values{}
+	}
+	for name, values := range b.sasQuery {
+		query[name] = values
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	if body != nil {
+		req.ContentLength = size
+		if size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureAPIVersion)
+
+	if b.sasQuery == nil {
+		b.sign(req)
+	}
+
+	return b.client.Do(req)
+}
+
+// sign authorizes req with the shared key scheme, see
+// https://learn.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key
+func (b *azureBackend) sign(req *http.Request) {
+	mac := hmac.New(sha256.New, b.accountKey)
+	mac.Write([]byte(azureStringToSign(req, b.config.Account)))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	req.Header.Set("Authorization", "SharedKey "+b.config.Account+":"+signature)
+}
+
+func azureStringToSign(req *http.Request, account string) string {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	var msHeaders []string
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-ms-") {
+			msHeaders = append(msHeaders, lower)
+		}
+	}
+	sort.Strings(msHeaders)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range msHeaders {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n")
+	}
+
+	var canonicalResource strings.Builder
+	canonicalResource.WriteString("/" + account + req.URL.EscapedPath())
+
+	query := req.URL.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		canonicalResource.WriteString("\n" + strings.ToLower(name) + ":" + strings.Join(values, ","))
+	}
+
+	return strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"",
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		canonicalHeaders.String() + canonicalResource.String(),
+	}, "\n")
+}
+
+func (b *azureBackend) createContainer(ctx context.Context) error {
+	query := url.Values{}
+	query.Set("restype", "container")
+
+	resp, err := b.do(ctx, http.MethodPut, "", query, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusConflict {
+		return azureResponseError(resp, http.MethodPut, b.config.Container)
+	}
+
+	return nil
+}
+
+func (b *azureBackend) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	blob, err := b.blobName(key)
+	if err != nil {
+		return err
+	}
+
+	// content that fits in a single block is uploaded with one Put Blob request,
+	// anything larger is streamed block by block and committed with Put Block List
+	buffer := make([]byte, azureBlockSize)
+	n, err := io.ReadFull(r, buffer)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return b.putBlob(ctx, key, blob, buffer[:n])
+	}
+	if err != nil {
+		return err
+	}
+
+	var blockIDs []string
+	for n > 0 {
+		blockID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("block-%08d", len(blockIDs))))
+		if err := b.putBlock(ctx, key, blob, blockID, buffer[:n]); err != nil {
+			return err
+		}
+		blockIDs = append(blockIDs, blockID)
+
+		n, err = io.ReadFull(r, buffer)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+	}
+
+	return b.putBlockList(ctx, key, blob, blockIDs)
+}
+
+func (b *azureBackend) putBlob(ctx context.Context, key, blob string, content []byte) error {
+	header := http.Header{}
+	header.Set("x-ms-blob-type", "BlockBlob")
+	header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := b.do(ctx, http.MethodPut, blob, nil, bytes.NewReader(content), int64(len(content)), header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return azureResponseError(resp, http.MethodPut, key)
+	}
+
+	return nil
+}
+
+func (b *azureBackend) putBlock(ctx context.Context, key, blob, blockID string, content []byte) error {
+	query := url.Values{}
+	query.Set("comp", "block")
+	query.Set("blockid", blockID)
+
+	resp, err := b.do(ctx, http.MethodPut, blob, query, bytes.NewReader(content), int64(len(content)), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return azureResponseError(resp, http.MethodPut, key)
+	}
+
+	return nil
+}
+
+func (b *azureBackend) putBlockList(ctx context.Context, key, blob string, blockIDs []string) error {
+	body, err := xml.Marshal(azureBlockList{Latest: blockIDs})
+	if err != nil {
+		return err
+	}
+	body = append([]byte(xml.Header), body...)
+
+	query := url.Values{}
+	query.Set("comp", "blocklist")
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/xml")
+	header.Set("x-ms-blob-content-type", "application/octet-stream")
+
+	resp, err := b.do(ctx, http.MethodPut, blob, query, bytes.NewReader(body), int64(len(body)), header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return azureResponseError(resp, http.MethodPut, key)
+	}
+
+	return nil
+}
+
+func (b *azureBackend) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	blob, err := b.blobName(key)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.do(ctx, http.MethodGet, blob, nil, nil, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, azureResponseError(resp, http.MethodGet, key)
+	}
+
+	return resp.Body, nil
+}
+
+func (b *azureBackend) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	blob, err := b.blobName(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	header.Set("x-ms-range", httpRange(offset, length))
+
+	resp, err := b.do(ctx, http.MethodGet, blob, nil, nil, 0, header)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		return rangeOfFullBody(resp.Body, offset, length)
+	}
+
+	if resp.StatusCode != http.StatusPartialContent {
+		defer resp.Body.Close()
+		return nil, azureResponseError(resp, http.MethodGet, key)
+	}
+
+	return resp.Body, nil
+}
+
+func (b *azureBackend) Delete(ctx context.Context, key string) error {
+	blob, err := b.blobName(key)
+	if err != nil {
+		return err
+	}
+
+	resp, err := b.do(ctx, http.MethodDelete, blob, nil, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		return azureResponseError(resp, http.MethodDelete, key)
+	}
+
+	return nil
+}
+
+func (b *azureBackend) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	blob, err := b.blobName(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	resp, err := b.do(ctx, http.MethodHead, blob, nil, nil, 0, nil)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ObjectInfo{}, azureResponseError(resp, http.MethodHead, key)
+	}
+
+	size, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+
+	return ObjectInfo{
+		Key:          strings.TrimPrefix(blob, b.config.Prefix+"/"),
+		Size:         size,
+		LastModified: lastModified,
+	}, nil
+}
+
+func (b *azureBackend) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	listPrefix := prefix
+	if b.config.Prefix != "" {
+		listPrefix = b.config.Prefix + "/" + prefix
+	}
+
+	var objects []ObjectInfo
+	var marker string
+	for {
+		query := url.Values{}
+		query.Set("restype", "container")
+		query.Set("comp", "list")
+		query.Set("prefix", listPrefix)
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+
+		resp, err := b.do(ctx, http.MethodGet, "", query, nil, 0, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := azureResponseError(resp, http.MethodGet, prefix)
+			resp.Body.Close()
+			return nil, err
+		}
+
+		var result azureListResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, blob := range result.Blobs.Blob {
+			key := blob.Name
+			if b.config.Prefix != "" {
+				key = strings.TrimPrefix(key, b.config.Prefix+"/")
+			}
+
+			lastModified, _ := http.ParseTime(blob.Properties.LastModified)
+			objects = append(objects, ObjectInfo{
+				Key:          key,
+				Size:         blob.Properties.ContentLength,
+				LastModified: lastModified,
+			})
+		}
+
+		if result.NextMarker == "" {
+			break
+		}
+		marker = result.NextMarker
+	}
+
+	return objects, nil
+}
+
+func azureResponseError(resp *http.Response, method, key string) error {
+	code := resp.Header.Get("x-ms-error-code")
+	if resp.StatusCode == http.StatusNotFound && (code == "" || code == "BlobNotFound") {
+		return ErrObjectNotFound
+	}
+
+	var azureErr azureError
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&azureErr); err == nil && azureErr.Code != "" {
+		return fmt.Errorf("azure: %s %q: %s: %s", method, key, azureErr.Code, azureErr.Message)
+	}
+
+	if code != "" {
+		return fmt.Errorf("azure: %s %q: %s", method, key, code)
+	}
+	return fmt.Errorf("azure: %s %q: unexpected status %s", method, key, resp.Status)
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The expected string follows the layout and the example given in the Azure
+// Shared Key documentation,
+// https://learn.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key
+func Test_AzureStringToSign_DocumentedLayout(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://myaccount.blob.core.windows.net/mycontainer?restype=container&comp=metadata&timeout=20", nil)
+	assert.NoError(t, err)
+	req.Header.Set("x-ms-date", "Sun, 11 Oct 2009 21:49:13 GMT")
+	req.Header.Set("x-ms-version", "2009-09-19")
+
+	expected := strings.Join([]string{
+		"GET", "", "", "", "", "", "", "", "", "", "", "",
+		"x-ms-date:Sun, 11 Oct 2009 21:49:13 GMT",
+		"x-ms-version:2009-09-19",
+		"/myaccount/mycontainer",
+		"comp:metadata",
+		"restype:container",
+		"timeout:20",
+	}, "\n")
+	assert.Equal(t, expected, azureStringToSign(req, "myaccount"))
+
+	req, err = http.NewRequest(http.MethodPut, "http://127.0.0.1:10000/devstoreaccount1/files/a/b.txt", strings.NewReader("hello"))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Range", "bytes=0-4")
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+
+	expected = strings.Join([]string{
+		"PUT", "", "", "5", "", "application/octet-stream", "", "", "", "", "", "bytes=0-4",
+		"x-ms-blob-type:BlockBlob",
+		"/devstoreaccount1/devstoreaccount1/files/a/b.txt",
+	}, "\n")
+	assert.Equal(t, expected, azureStringToSign(req, "devstoreaccount1"))
+}
@@ -0,0 +1,443 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+// Encrypted objects start with a header holding the object's data key wrapped
+// by the master key, followed by the content sealed with AES-256-GCM in chunks
+// of encryptionChunkSize bytes. Chunks are sealed separately so ranges can be
+// decrypted without reading the whole object. The nonce of a chunk is its
+// index, with the last byte set for the final chunk so truncation is detected.
+const (
+	encryptionMagic     = "FPENC001"
+	encryptionKeySize   = 32
+	encryptionChunkSize = 64 << 10
+	gcmNonceSize        = 12
+	gcmTagSize          = 16
+
+	wrappedKeySize      = gcmNonceSize + encryptionKeySize + gcmTagSize
+	encryptionHeaderLen = int64(len(encryptionMagic) + wrappedKeySize)
+	encryptedChunkSize  = encryptionChunkSize + gcmTagSize
+)
+
+var (
+	ErrInvalidMasterKey = errors.New("storage: master key must be 32 bytes")
+	ErrObjectCorrupted  = errors.New("storage: encrypted object is corrupted")
+)
+
+type (
+	// Encrypter is implemented by backends that encrypt content at rest.
+	Encrypter interface {
+		// EncryptObject encrypts a plaintext object in place. It reports false
+		// when the object was already encrypted.
+		EncryptObject(ctx context.Context, key string) (bool, error)
+	}
+
+	encryptedBackend struct {
+		backend   Backend
+		masterKey cipher.AEAD
+	}
+)
+
+// NewEncryptedBackend wraps backend so every object is encrypted with its own
+// data key, which is in turn wrapped with masterKey. Objects written before
+// encryption was enabled are detected and still read as plaintext until they
+// are converted with EncryptObject. Stat and List report the stored sizes, the
+// plaintext size of an object is available through Open.
+func NewEncryptedBackend(backend Backend, masterKey []byte) (Backend, error) {
+	if len(masterKey) != encryptionKeySize {
+		return nil, ErrInvalidMasterKey
+	}
+
+	aead, err := newGCM(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptedBackend{
+		backend:   backend,
+		masterKey: aead,
+	}, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(index uint64, final bool) []byte {
+	nonce := make([]byte, gcmNonceSize)
+	binary.BigEndian.PutUint64(nonce[3:11], index)
+	if final {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+func encryptedSize(size int64) int64 {
+	if size < 0 {
+		return -1
+	}
+
+	chunks := (size + encryptionChunkSize - 1) / encryptionChunkSize
+	if chunks == 0 {
+		chunks = 1
+	}
+	return encryptionHeaderLen + size + chunks*gcmTagSize
+}
+
+func plaintextSize(size int64) int64 {
+	size -= encryptionHeaderLen
+	chunks := (size + encryptedChunkSize - 1) / encryptedChunkSize
+	return size - chunks*gcmTagSize
+}
+
+func (b *encryptedBackend) wrapKey(dataKey []byte) ([]byte, error) {
+	nonce := make([]byte, gcmNonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return b.masterKey.Seal(nonce, nonce, dataKey, []byte(encryptionMagic)), nil
+}
+
+// unwrapKey returns the data key of an object from its header, or nil when
+// header does not belong to an encrypted object.
+func (b *encryptedBackend) unwrapKey(header []byte) (cipher.AEAD, error) {
+	if int64(len(header)) < encryptionHeaderLen || string(header[:len(encryptionMagic)]) != encryptionMagic {
+		return nil, nil
+	}
+
+	wrapped := header[len(encryptionMagic):encryptionHeaderLen]
+	dataKey, err := b.masterKey.Open(nil, wrapped[:gcmNonceSize], wrapped[gcmNonceSize:], []byte(encryptionMagic))
+	if err != nil {
+		return nil, ErrObjectCorrupted
+	}
+	return newGCM(dataKey)
+}
+
+// readHeader returns the data key of the object at key, or nil when the object
+// is stored as plaintext.
+func (b *encryptedBackend) readHeader(ctx context.Context, key string) (cipher.AEAD, error) {
+	reader, err := b.backend.GetRange(ctx, key, 0, encryptionHeaderLen)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	header, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return b.unwrapKey(header)
+}
+
+func (b *encryptedBackend) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	dataKey := make([]byte, encryptionKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return err
+	}
+
+	wrapped, err := b.wrapKey(dataKey)
+	if err != nil {
+		return err
+	}
+
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return err
+	}
+
+	content := &encryptingReader{
+		aead:    aead,
+		src:     bufio.NewReaderSize(r, encryptionChunkSize),
+		buf:     make([]byte, encryptionChunkSize),
+		out:     make([]byte, 0, encryptedChunkSize),
+		pending: append([]byte(encryptionMagic), wrapped...),
+	}
+	return b.backend.Put(ctx, key, content, encryptedSize(size))
+}
+
+func (b *encryptedBackend) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	reader, err := b.backend.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, encryptionHeaderLen)
+	n, err := io.ReadFull(reader, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		reader.Close()
+		return nil, err
+	}
+
+	aead, err := b.unwrapKey(header[:n])
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+
+	if aead == nil {
+		return struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(header[:n]), reader), reader}, nil
+	}
+
+	return &decryptingReader{
+		aead:      aead,
+		src:       reader,
+		buf:       make([]byte, encryptedChunkSize),
+		out:       make([]byte, 0, encryptionChunkSize),
+		remaining: -1,
+	}, nil
+}
+
+func (b *encryptedBackend) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	aead, err := b.readHeader(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return b.getRange(ctx, key, aead, offset, length)
+}
+
+// getRange reads a plaintext range of an object whose header has already been
+// read. aead is nil for objects stored as plaintext.
+func (b *encryptedBackend) getRange(ctx context.Context, key string, aead cipher.AEAD, offset, length int64) (io.ReadCloser, error) {
+	if aead == nil {
+		return b.backend.GetRange(ctx, key, offset, length)
+	}
+
+	if length == 0 {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+
+	first := offset / encryptionChunkSize
+	innerLength := int64(-1)
+	if length > 0 {
+		last := (offset + length - 1) / encryptionChunkSize
+		innerLength = (last - first + 1) * encryptedChunkSize
+	}
+
+	reader, err := b.backend.GetRange(ctx, key, encryptionHeaderLen+first*encryptedChunkSize, innerLength)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decryptingReader{
+		aead:      aead,
+		src:       reader,
+		buf:       make([]byte, encryptedChunkSize),
+		out:       make([]byte, 0, encryptionChunkSize),
+		index:     uint64(first),
+		skip:      int(offset % encryptionChunkSize),
+		remaining: length,
+	}, nil
+}
+
+// Open reads the object's header once, so ranged reads through the returned
+// Object cost a single round trip each.
+func (b *encryptedBackend) Open(ctx context.Context, key string) (Object, error) {
+	info, err := b.backend.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var aead cipher.AEAD
+	if info.Size >= encryptionHeaderLen {
+		if aead, err = b.readHeader(ctx, key); err != nil {
+			return nil, err
+		}
+	}
+
+	size := info.Size
+	if aead != nil {
+		size = plaintextSize(size)
+	}
+
+	return &object{
+		ctx: ctx,
+		getRange: func(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+			return b.getRange(ctx, key, aead, offset, length)
+		},
+		key:  key,
+		size: size,
+	}, nil
+}
+
+func (b *encryptedBackend) Delete(ctx context.Context, key string) error {
+	return b.backend.Delete(ctx, key)
+}
+
+func (b *encryptedBackend) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	return b.backend.Stat(ctx, key)
+}
+
+func (b *encryptedBackend) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	return b.backend.List(ctx, prefix)
+}
+
+func (b *encryptedBackend) EncryptObject(ctx context.Context, key string) (bool, error) {
+	info, err := b.backend.Stat(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	if info.Size >= encryptionHeaderLen {
+		aead, err := b.readHeader(ctx, key)
+		if err != nil || aead != nil {
+			return false, err
+		}
+	}
+
+	// the plaintext is spooled first so the object is never read and replaced
+	// at the same time
+	tmp, err := os.CreateTemp("", "encrypt-*")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	reader, err := b.backend.Get(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	size, err := io.Copy(tmp, reader)
+	reader.Close()
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+
+	if err := b.Put(ctx, key, tmp, size); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+type encryptingReader struct {
+	aead    cipher.AEAD
+	src     *bufio.Reader
+	buf     []byte
+	out     []byte
+	pending []byte
+	index   uint64
+	done    bool
+}
+
+func (r *encryptingReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+
+		n, err := io.ReadFull(r.src, r.buf)
+		final := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !final {
+			return 0, err
+		}
+
+		// a full chunk is only final when nothing follows it
+		if !final {
+			if _, err := r.src.Peek(1); err == io.EOF {
+				final = true
+			} else if err != nil {
+				return 0, err
+			}
+		}
+
+		r.pending = r.aead.Seal(r.out[:0], chunkNonce(r.index, final), r.buf[:n], nil)
+		r.index++
+		r.done = final
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+type decryptingReader struct {
+	aead      cipher.AEAD
+	src       io.ReadCloser
+	buf       []byte
+	out       []byte
+	plain     []byte
+	index     uint64
+	skip      int
+	remaining int64
+	final     bool
+}
+
+func (r *decryptingReader) Read(p []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+
+	for len(r.plain) == 0 {
+		if r.final {
+			return 0, io.EOF
+		}
+
+		n, err := io.ReadFull(r.src, r.buf)
+		if err == io.EOF {
+			return 0, ErrObjectCorrupted
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+
+		// a short chunk has to be the final one, a full chunk may be either
+		var plain []byte
+		if err == nil {
+			plain, err = r.aead.Open(r.out[:0], chunkNonce(r.index, false), r.buf[:n], nil)
+		}
+		if plain == nil {
+			plain, err = r.aead.Open(r.out[:0], chunkNonce(r.index, true), r.buf[:n], nil)
+			r.final = true
+		}
+		if err != nil {
+			return 0, ErrObjectCorrupted
+		}
+		r.index++
+
+		if r.skip > 0 {
+			if r.skip > len(plain) {
+				r.skip = len(plain)
+			}
+			plain = plain[r.skip:]
+			r.skip = 0
+		}
+		r.plain = plain
+	}
+
+	if r.remaining >= 0 && int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	if r.remaining > 0 {
+		r.remaining -= int64(n)
+	}
+	return n, nil
+}
+
+func (r *decryptingReader) Close() error {
+	return r.src.Close()
+}
@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type localBackend struct {
+	root string
+}
+
+func NewLocalBackend(root string) Backend {
+	return &localBackend{
+		root: root,
+	}
+}
+
+func (b *localBackend) path(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(b.root, filepath.FromSlash(key)), nil
+}
+
+func (b *localBackend) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	filePath, err := b.path(key)
+	if err != nil {
+		return err
+	}
+
+	directory := filepath.Dir(filePath)
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return err
+	}
+
+	// write to a temporary file first so readers never observe a partial object
+	tmp, err := os.CreateTemp(directory, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filePath)
+}
+
+func (b *localBackend) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	filePath, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func (b *localBackend) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	reader, err := b.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	file := reader.(*os.File)
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if length < 0 {
+		return file, nil
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(file, length), file}, nil
+}
+
+func (b *localBackend) Delete(ctx context.Context, key string) error {
+	filePath, err := b.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(filePath); err != nil {
+		if os.IsNotExist(err) {
+			return ErrObjectNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (b *localBackend) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	info, err := os.Stat(filepath.Join(b.root, filepath.FromSlash(key)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ObjectInfo{}, ErrObjectNotFound
+		}
+		return ObjectInfo{}, err
+	}
+
+	if info.IsDir() {
+		return ObjectInfo{}, ErrObjectNotFound
+	}
+
+	return ObjectInfo{
+		Key:          key,
+		Size:         info.Size(),
+		LastModified: info.ModTime(),
+	}, nil
+}
+
+func (b *localBackend) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+
+	// only the directory holding the prefix is walked, a missing directory
+	// simply has no objects
+	dir := prefix
+	if !strings.HasSuffix(dir, "/") {
+		dir = path.Dir(dir)
+	}
+
+	start := b.root
+	if dir, err := CleanKey(dir); err == nil {
+		start = filepath.Join(b.root, filepath.FromSlash(dir))
+	}
+
+	err := filepath.WalkDir(start, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(b.root, filePath)
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		objects = append(objects, ObjectInfo{
+			Key:          key,
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+
+	return objects, nil
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var errNegativeOffset = errors.New("storage: negative offset")
+
+// Object is a seekable view over a stored object. It only opens a ranged read
+// on the backend when data is actually read, so seeking around (as
+// http.ServeContent does for Range requests) costs no extra round trips.
+type Object interface {
+	io.ReadSeekCloser
+	Size() int64
+}
+
+// opener is implemented by backends that need more than Stat to open an
+// object, such as the encrypted backend which reads the object's key once.
+type opener interface {
+	Open(ctx context.Context, key string) (Object, error)
+}
+
+type rangeFunc func(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+
+// Reads are issued as ranges of at most window bytes. The window starts small
+// so a short Range request does not fetch the rest of the object, and doubles
+// while the object is read sequentially.
+const (
+	minReadWindow = 64 << 10
+	maxReadWindow = 8 << 20
+)
+
+type object struct {
+	ctx      context.Context
+	getRange rangeFunc
+	key      string
+	size     int64
+	offset   int64
+	window   int64
+	end      int64
+	body     io.ReadCloser
+}
+
+// Open returns a lazily read Object for key. The object's size is looked up
+// immediately so a missing key fails here with ErrObjectNotFound.
+func Open(ctx context.Context, backend Backend, key string) (Object, error) {
+	if o, ok := backend.(opener); ok {
+		return o.Open(ctx, key)
+	}
+
+	info, err := backend.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &object{
+		ctx:      ctx,
+		getRange: backend.GetRange,
+		key:      key,
+		size:     info.Size,
+	}, nil
+}
+
+func (o *object) Size() int64 {
+	return o.size
+}
+
+func (o *object) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+
+	if o.body == nil {
+		if o.window < minReadWindow {
+			o.window = minReadWindow
+		}
+
+		length := o.size - o.offset
+		if length > o.window {
+			length = o.window
+		}
+
+		body, err := o.getRange(o.ctx, o.key, o.offset, length)
+		if err != nil {
+			return 0, err
+		}
+		o.body = body
+		o.end = o.offset + length
+
+		if o.window < maxReadWindow {
+			o.window *= 2
+		}
+	}
+
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+
+	// the end of a window is not the end of the object
+	if err == io.EOF && o.offset < o.size {
+		o.body.Close()
+		o.body = nil
+		err = nil
+		if o.offset < o.end {
+			err = io.ErrUnexpectedEOF
+		}
+	}
+	return n, err
+}
+
+func (o *object) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += o.offset
+	case io.SeekEnd:
+		offset += o.size
+	default:
+		return 0, errors.New("storage: invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, errNegativeOffset
+	}
+
+	if offset != o.offset && o.body != nil {
+		o.body.Close()
+		o.body = nil
+		o.window = 0
+	}
+	o.offset = offset
+	return offset, nil
+}
+
+func (o *object) Close() error {
+	if o.body == nil {
+		return nil
+	}
+
+	err := o.body.Close()
+	o.body = nil
+	return err
+}
@@ -0,0 +1,457 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Service         = "s3"
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3EmptyPayload    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	s3TimeFormat      = "20060102T150405Z"
+	s3DateFormat      = "20060102"
+)
+
+type (
+	S3Config struct {
+		// Endpoint is the base URL of the S3 API, e.g. "http://minio:9000".
+		// When empty the AWS endpoint of Region is used.
+		Endpoint        string
+		Region          string
+		Bucket          string
+		Prefix          string
+		AccessKeyID     string
+		SecretAccessKey string
+		// UsePathStyle addresses the bucket as "<endpoint>/<bucket>/<key>" instead
+		// of "<bucket>.<endpoint>/<key>", which most S3 compatible servers need.
+		UsePathStyle bool
+	}
+
+	s3Backend struct {
+		config   S3Config
+		endpoint *url.URL
+		client   *http.Client
+	}
+
+	s3Error struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+
+	s3ListResult struct {
+		Contents []struct {
+			Key          string    `xml:"Key"`
+			Size         int64     `xml:"Size"`
+			LastModified time.Time `xml:"LastModified"`
+		} `xml:"Contents"`
+		IsTruncated           bool   `xml:"IsTruncated"`
+		NextContinuationToken string `xml:"NextContinuationToken"`
+	}
+)
+
+func NewS3Backend(config S3Config) (Backend, error) {
+	if config.Bucket == "" {
+		return nil, errors.New("s3: bucket is required")
+	}
+
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+
+	if config.Endpoint == "" {
+		config.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", config.Region)
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("s3: invalid endpoint: %w", err)
+	}
+
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("s3: invalid endpoint %q", config.Endpoint)
+	}
+
+	config.Prefix = strings.Trim(config.Prefix, "/")
+
+	return &s3Backend{
+		config:   config,
+		endpoint: endpoint,
+		client:   &http.Client{},
+	}, nil
+}
+
+func (b *s3Backend) objectKey(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	if b.config.Prefix != "" {
+		key = b.config.Prefix + "/" + key
+	}
+	return key, nil
+}
+
+func (b *s3Backend) objectURL(key string, query url.Values) *url.URL {
+	u := *b.endpoint
+	if b.config.UsePathStyle {
+		u.Path = b.endpoint.Path + "/" + b.config.Bucket + "/" + key
+	} else {
+		u.Host = b.config.Bucket + "." + b.endpoint.Host
+		u.Path = b.endpoint.Path + "/" + key
+	}
+	u.RawPath = s3EscapePath(u.Path)
+	u.RawQuery = s3CanonicalQuery(query)
+	return &u
+}
+
+func (b *s3Backend) do(ctx context.Context, method, key string, query url.Values, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, b.objectURL(key, query).String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	payloadHash := s3EmptyPayload
+	if body != nil {
+		req.ContentLength = size
+		payloadHash = s3UnsignedPayload
+		if size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+
+	b.sign(req, payloadHash, time.Now().UTC())
+	return b.client.Do(req)
+}
+
+func (b *s3Backend) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format(s3TimeFormat)
+	scope := strings.Join([]string{now.Format(s3DateFormat), b.config.Region, s3Service, "aws4_request"}, "/")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "host" || name == "range" || strings.HasPrefix(name, "x-amz-") || name == "content-type" {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		amzDate,
+		scope,
+		hex.EncodeToString(s3Hash([]byte(canonicalRequest))),
+	}, "\n")
+
+	signingKey := s3HMAC([]byte("AWS4"+b.config.SecretAccessKey), []byte(now.Format(s3DateFormat)))
+	signingKey = s3HMAC(signingKey, []byte(b.config.Region))
+	signingKey = s3HMAC(signingKey, []byte(s3Service))
+	signingKey = s3HMAC(signingKey, []byte("aws4_request"))
+	signature := hex.EncodeToString(s3HMAC(signingKey, []byte(stringToSign)))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, b.config.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func (b *s3Backend) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	objectKey, err := b.objectKey(key)
+	if err != nil {
+		return err
+	}
+
+	// a plain PUT needs the content length up front, spool unknown sizes to disk
+	if size < 0 {
+		tmp, err := os.CreateTemp("", "s3-upload-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		if size, err = io.Copy(tmp, r); err != nil {
+			return err
+		}
+
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		r = tmp
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := b.do(ctx, http.MethodPut, objectKey, nil, io.NopCloser(r), size, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s3ResponseError(resp, http.MethodPut, key)
+	}
+
+	return nil
+}
+
+func (b *s3Backend) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	objectKey, err := b.objectKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.do(ctx, http.MethodGet, objectKey, nil, nil, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, s3ResponseError(resp, http.MethodGet, key)
+	}
+
+	return resp.Body, nil
+}
+
+func (b *s3Backend) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	objectKey, err := b.objectKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	header.Set("Range", httpRange(offset, length))
+
+	resp, err := b.do(ctx, http.MethodGet, objectKey, nil, nil, 0, header)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		return rangeOfFullBody(resp.Body, offset, length)
+	}
+
+	if resp.StatusCode != http.StatusPartialContent {
+		defer resp.Body.Close()
+		return nil, s3ResponseError(resp, http.MethodGet, key)
+	}
+
+	return resp.Body, nil
+}
+
+func (b *s3Backend) Delete(ctx context.Context, key string) error {
+	objectKey, err := b.objectKey(key)
+	if err != nil {
+		return err
+	}
+
+	resp, err := b.do(ctx, http.MethodDelete, objectKey, nil, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return s3ResponseError(resp, http.MethodDelete, key)
+	}
+
+	return nil
+}
+
+func (b *s3Backend) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	objectKey, err := b.objectKey(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	resp, err := b.do(ctx, http.MethodHead, objectKey, nil, nil, 0, nil)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ObjectInfo{}, s3ResponseError(resp, http.MethodHead, key)
+	}
+
+	size, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+
+	return ObjectInfo{
+		Key:          strings.TrimPrefix(objectKey, b.config.Prefix+"/"),
+		Size:         size,
+		LastModified: lastModified,
+	}, nil
+}
+
+func (b *s3Backend) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	listPrefix := prefix
+	if b.config.Prefix != "" {
+		listPrefix = b.config.Prefix + "/" + prefix
+	}
+
+	var objects []ObjectInfo
+	var continuationToken string
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", listPrefix)
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		resp, err := b.do(ctx, http.MethodGet, "", query, nil, 0, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := s3ResponseError(resp, http.MethodGet, prefix)
+			resp.Body.Close()
+			return nil, err
+		}
+
+		var result s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, content := range result.Contents {
+			key := content.Key
+			if b.config.Prefix != "" {
+				key = strings.TrimPrefix(key, b.config.Prefix+"/")
+			}
+
+			objects = append(objects, ObjectInfo{
+				Key:          key,
+				Size:         content.Size,
+				LastModified: content.LastModified,
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		continuationToken = result.NextContinuationToken
+	}
+
+	return objects, nil
+}
+
+func s3ResponseError(resp *http.Response, method, key string) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrObjectNotFound
+	}
+
+	var s3Err s3Error
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&s3Err); err != nil || s3Err.Code == "" {
+		return fmt.Errorf("s3: %s %q: unexpected status %s", method, key, resp.Status)
+	}
+
+	if s3Err.Code == "NoSuchKey" {
+		return ErrObjectNotFound
+	}
+	return fmt.Errorf("s3: %s %q: %s: %s", method, key, s3Err.Code, s3Err.Message)
+}
+
+// s3EscapePath URI-encodes every path segment as required by SigV4.
+func s3EscapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, s3Escape(key)+"="+s3Escape(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func s3Escape(s string) string {
+	var builder strings.Builder
+	for _, c := range []byte(s) {
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			builder.WriteByte(c)
+		} else {
+			fmt.Fprintf(&builder, "%%%02X", c)
+		}
+	}
+	return builder.String()
+}
+
+func s3Hash(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+func s3HMAC(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrObjectNotFound = errors.New("object not found")
+	ErrInvalidKey     = errors.New("invalid object key")
+)
+
+type (
+	// Backend stores file content as objects addressed by slash separated keys,
+	// e.g. "<user_id>/<file_id>.pdf". Implementations must be safe for concurrent use.
+	Backend interface {
+		// Put streams r into the object at key, replacing any existing object.
+		// size is the number of bytes r will yield, or -1 when unknown.
+		Put(ctx context.Context, key string, r io.Reader, size int64) error
+		Get(ctx context.Context, key string) (io.ReadCloser, error)
+		// GetRange streams length bytes starting at offset, or everything after
+		// offset when length is negative.
+		GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+		Delete(ctx context.Context, key string) error
+		Stat(ctx context.Context, key string) (ObjectInfo, error)
+		List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	}
+
+	ObjectInfo struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+	}
+)
+
+func httpRange(offset, length int64) string {
+	if length < 0 {
+		return fmt.Sprintf("bytes=%d-", offset)
+	}
+	return fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+}
+
+// rangeOfFullBody cuts the requested range out of a response whose server
+// ignored the Range header and sent the whole object.
+func rangeOfFullBody(body io.ReadCloser, offset, length int64) (io.ReadCloser, error) {
+	if _, err := io.CopyN(io.Discard, body, offset); err != nil && err != io.EOF {
+		body.Close()
+		return nil, err
+	}
+
+	if length < 0 {
+		return body, nil
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(body, length), body}, nil
+}
+
+// CleanKey normalizes key and rejects keys that are empty or try to escape the
+// storage root.
+func CleanKey(key string) (string, error) {
+	key = strings.ReplaceAll(key, "\\", "/")
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+
+	cleaned := strings.TrimPrefix(path.Clean("/"+key), "/")
+	if cleaned == "" {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
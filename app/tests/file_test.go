@@ -8,26 +8,40 @@ import (
 	"FP-DevOps/middleware"
 	"FP-DevOps/repository"
 	"FP-DevOps/service"
+	"FP-DevOps/storage"
+	"archive/zip"
 	"bytes"
+	"context"
 	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
 func SetupControllerFile() controller.FileController {
 	var (
-		db             = config.SetUpDatabaseConnection()
-		fileRepo       = repository.NewFileRepository(db)
-		jwtService     = config.NewJWTService()
-		fileService    = service.NewFileService(fileRepo)
-		fileController = controller.NewFileController(fileService, jwtService)
+		db               = config.SetUpDatabaseConnection()
+		store            = config.SetUpStorageBackend()
+		fileRepo         = repository.NewFileRepository(db, store)
+		jwtService       = config.NewJWTService()
+		thumbnailService = service.NewThumbnailService(repository.NewThumbnailRepository(store), fileRepo)
+		fileService      = service.NewFileService(fileRepo, repository.NewUserRepository(db), repository.NewFolderRepository(db), service.NewAuthorizer(repository.NewFileGrantRepository(db), repository.NewTeamRepository(db)), thumbnailService)
+		fileController   = controller.NewFileController(fileService, jwtService)
 	)
+	go thumbnailService.Run(context.Background())
 
 	return fileController
 }
@@ -317,6 +331,119 @@ func Test_FileSharing_AccessPublicFile_OK(t *testing.T) {
 	assert.Equal(t, "public content", recorder.Body.String())
 }
 
+func Test_FileDownload_Range_OK(t *testing.T) {
+	r := SetUpRoutes()
+	fc := SetupControllerFile()
+	jwtService := config.NewJWTService()
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	r.GET("/api/file/:id", middleware.AuthenticateIfExists(jwtService), fc.GetFileByID)
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+
+	file := uploadTestFile(t, r, token, "range-file.txt", "0123456789")
+
+	req, _ := http.NewRequest("GET", "/api/file/"+file.ID, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Range", "bytes=2-5")
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusPartialContent, recorder.Code)
+	assert.Equal(t, "2345", recorder.Body.String())
+	assert.Equal(t, "bytes 2-5/10", recorder.Header().Get("Content-Range"))
+
+	etag := recorder.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	req, _ = http.NewRequest("GET", "/api/file/"+file.ID, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("If-None-Match", etag)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+	assert.Empty(t, recorder.Body.String())
+}
+
+func Test_FileUpload_Deduplicated_OK(t *testing.T) {
+	r := SetUpRoutes()
+	fc := SetupControllerFile()
+	jwtService := config.NewJWTService()
+	CleanUpTestUsers()
+	userToken := loginTestAccount(t, "user", "user123")
+	adminToken := loginTestAccount(t, "admin", "admin123")
+
+	r.GET("/api/file/:id", middleware.AuthenticateIfExists(jwtService), fc.GetFileByID)
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+	r.DELETE("/api/file/:id", middleware.Authenticate(jwtService), fc.DeleteByID)
+	r.DELETE("/api/trash/:id", middleware.Authenticate(jwtService), fc.DeletePermanentlyByID)
+
+	content := "duplicated content " + uuid.New().String()
+	first := uploadTestFile(t, r, userToken, "first-copy.txt", content)
+	second := uploadTestFile(t, r, adminToken, "second-copy.txt", content)
+
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.NotEmpty(t, first.Checksum)
+	assert.Equal(t, first.Checksum, second.Checksum)
+
+	db := config.SetUpDatabaseConnection()
+	var blob entity.Blob
+	assert.NoError(t, db.Where("checksum = ?", first.Checksum).First(&blob).Error)
+	assert.Equal(t, int64(2), blob.RefCount)
+
+	objects, err := config.SetUpStorageBackend().List(context.Background(), path.Dir(blob.Path)+"/")
+	assert.NoError(t, err)
+	stored := 0
+	for _, object := range objects {
+		if strings.HasSuffix(object.Key, first.Checksum) {
+			stored++
+		}
+	}
+	assert.Equal(t, 1, stored)
+
+	req, _ := http.NewRequest("DELETE", "/api/file/"+first.ID, nil)
+	req.Header.Set("Authorization", "Bearer "+userToken)
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// a file in the trash keeps its reference
+	assert.NoError(t, db.Where("checksum = ?", first.Checksum).First(&blob).Error)
+	assert.Equal(t, int64(2), blob.RefCount)
+
+	req, _ = http.NewRequest("DELETE", "/api/trash/"+first.ID, nil)
+	req.Header.Set("Authorization", "Bearer "+userToken)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	assert.NoError(t, db.Where("checksum = ?", first.Checksum).First(&blob).Error)
+	assert.Equal(t, int64(1), blob.RefCount)
+
+	req, _ = http.NewRequest("GET", "/api/file/"+second.ID, nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, content, recorder.Body.String())
+
+	// releasing the last reference removes the blob after the commit
+	blobPath := blob.Path
+	for _, url := range []string{"/api/file/", "/api/trash/"} {
+		req, _ = http.NewRequest("DELETE", url+second.ID, nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		recorder = httptest.NewRecorder()
+		r.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	assert.ErrorIs(t, db.Where("checksum = ?", first.Checksum).First(&entity.Blob{}).Error, gorm.ErrRecordNotFound)
+	_, err = config.SetUpStorageBackend().Stat(context.Background(), blobPath)
+	assert.Equal(t, storage.ErrObjectNotFound, err)
+}
+
 func Test_FileUpload_OK(t *testing.T) {
 	r := SetUpRoutes()
 	fc := SetupControllerFile()
@@ -351,3 +478,646 @@ func Test_FileUpload_Unauthorized(t *testing.T) {
 
 	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
 }
+
+func Test_FileUpload_TooLarge(t *testing.T) {
+	t.Setenv("MAX_UPLOAD_SIZE_MB", "1")
+
+	r := SetUpRoutes()
+	fc := SetupControllerFile()
+	jwtService := config.NewJWTService()
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "too-large.bin")
+	assert.NoError(t, err)
+	_, err = part.Write(make([]byte, 1<<20+1))
+	assert.NoError(t, err)
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/api/file", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
+
+func Test_FileUpload_QuotaExceeded(t *testing.T) {
+	r := SetUpRoutes()
+	fc := SetupControllerFile()
+	jwtService := config.NewJWTService()
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	db := config.SetUpDatabaseConnection()
+	assert.NoError(t, db.Model(&entity.User{}).Where("username = ?", "user").Update("storage_quota", 16).Error)
+
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+
+	uploadTestFile(t, r, token, "within-quota.txt", "0123456789")
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "over-quota.txt")
+	assert.NoError(t, err)
+	_, err = part.Write([]byte("0123456789"))
+	assert.NoError(t, err)
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/api/file", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusInsufficientStorage, recorder.Code)
+}
+
+func Test_FileUpload_Multiple_PartialSuccess(t *testing.T) {
+	r := SetUpRoutes()
+	fc := SetupControllerFile()
+	jwtService := config.NewJWTService()
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	for i, name := range []string{"first.txt", "second.txt", "third.txt"} {
+		// the folder only applies to the files after it
+		if i == 2 {
+			assert.NoError(t, writer.WriteField("folder_id", uuid.New().String()))
+		}
+		part, err := writer.CreateFormFile("file", name)
+		assert.NoError(t, err)
+		_, err = part.Write([]byte("content of " + name))
+		assert.NoError(t, err)
+	}
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/api/file", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusMultiStatus, recorder.Code)
+
+	var response struct {
+		Data []dto.FileUploadResult `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	if assert.Len(t, response.Data, 3) {
+		assert.Equal(t, "first.txt", response.Data[0].Filename)
+		assert.Equal(t, http.StatusCreated, response.Data[0].Status)
+		assert.NotNil(t, response.Data[0].File)
+		assert.Equal(t, http.StatusCreated, response.Data[1].Status)
+		assert.Equal(t, "third.txt", response.Data[2].Filename)
+		assert.Equal(t, http.StatusNotFound, response.Data[2].Status)
+		assert.Equal(t, dto.ErrFolderNotFound.Error(), response.Data[2].Error)
+		assert.Nil(t, response.Data[2].File)
+	}
+}
+
+func Test_FileUpload_Multiple_QuotaExceeded(t *testing.T) {
+	r := SetUpRoutes()
+	fc := SetupControllerFile()
+	jwtService := config.NewJWTService()
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	db := config.SetUpDatabaseConnection()
+	assert.NoError(t, db.Model(&entity.User{}).Where("username = ?", "user").Update("storage_quota", 16).Error)
+
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+
+	// the files are stored concurrently, each fits in the quota on its own
+	// but only one of them fits once the others are counted
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	for _, name := range []string{"first.txt", "second.txt", "third.txt"} {
+		part, err := writer.CreateFormFile("file", name)
+		assert.NoError(t, err)
+		_, err = part.Write([]byte("0123456789"))
+		assert.NoError(t, err)
+	}
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/api/file", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusMultiStatus, recorder.Code)
+
+	var response struct {
+		Data []dto.FileUploadResult `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	created := 0
+	for _, result := range response.Data {
+		if result.Status == http.StatusCreated {
+			created++
+		} else {
+			assert.Equal(t, http.StatusInsufficientStorage, result.Status)
+		}
+	}
+	assert.Equal(t, 1, created)
+}
+
+func uploadTestVersion(t *testing.T, router http.Handler, token, fileID, content string) dto.FileResponse {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "new-version.txt")
+	assert.NoError(t, err)
+	_, err = part.Write([]byte(content))
+	assert.NoError(t, err)
+	writer.Close()
+
+	req, _ := http.NewRequest("PUT", "/api/file/"+fileID, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	var response struct {
+		Data dto.FileResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	return response.Data
+}
+
+func Test_FileVersion_Restore_OK(t *testing.T) {
+	t.Setenv("FILE_VERSION_RETENTION", "1")
+
+	r := SetUpRoutes()
+	fc := SetupControllerFile()
+	jwtService := config.NewJWTService()
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+	r.GET("/api/file/:id", middleware.AuthenticateIfExists(jwtService), fc.GetFileByID)
+	r.PUT("/api/file/:id", middleware.Authenticate(jwtService), fc.CreateVersion)
+	r.GET("/api/file/:id/versions", middleware.Authenticate(jwtService), fc.GetVersions)
+	r.GET("/api/file/:id/versions/:version", middleware.Authenticate(jwtService), fc.GetVersion)
+	r.POST("/api/file/:id/versions/:version/restore", middleware.Authenticate(jwtService), fc.RestoreVersion)
+
+	file := uploadTestFile(t, r, token, "report.txt", "first draft")
+	second := uploadTestVersion(t, r, token, file.ID, "second draft")
+	assert.Equal(t, file.ID, second.ID)
+	assert.Equal(t, "report.txt", second.Filename)
+	assert.Equal(t, 2, second.Version)
+
+	req, _ := http.NewRequest("GET", "/api/file/"+file.ID+"/versions/1", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "first draft", recorder.Body.String())
+
+	req, _ = http.NewRequest("POST", "/api/file/"+file.ID+"/versions/1/restore", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	req, _ = http.NewRequest("GET", "/api/file/"+file.ID, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, "first draft", recorder.Body.String())
+
+	// restoring kept the replaced second version, a fourth version pushes it
+	// out of the retention of one
+	uploadTestVersion(t, r, token, file.ID, "final draft")
+
+	req, _ = http.NewRequest("GET", "/api/file/"+file.ID+"/versions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var versions struct {
+		Data []dto.FileVersionResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &versions))
+	if assert.Len(t, versions.Data, 2) {
+		assert.Equal(t, 4, versions.Data[0].Version)
+		assert.True(t, versions.Data[0].Current)
+		assert.Equal(t, 3, versions.Data[1].Version)
+		assert.False(t, versions.Data[1].Current)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/file/"+file.ID+"/versions/2", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func Test_FileTrash_Restore_OK(t *testing.T) {
+	r := SetUpRoutes()
+	fc := SetupControllerFile()
+	jwtService := config.NewJWTService()
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+	r.GET("/api/file/:id", middleware.AuthenticateIfExists(jwtService), fc.GetFileByID)
+	r.DELETE("/api/file/:id", middleware.Authenticate(jwtService), fc.DeleteByID)
+	r.GET("/api/trash", middleware.Authenticate(jwtService), fc.GetTrash)
+	r.POST("/api/trash/:id/restore", middleware.Authenticate(jwtService), fc.RestoreByID)
+
+	file := uploadTestFile(t, r, token, "trashed.txt", "content kept in the trash")
+
+	req, _ := http.NewRequest("DELETE", "/api/file/"+file.ID, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	req, _ = http.NewRequest("GET", "/api/file/"+file.ID, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	req, _ = http.NewRequest("GET", "/api/trash", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var trash struct {
+		Data []dto.FileResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &trash))
+	if assert.Len(t, trash.Data, 1) {
+		assert.Equal(t, file.ID, trash.Data[0].ID)
+		assert.NotNil(t, trash.Data[0].DeletedAt)
+	}
+
+	req, _ = http.NewRequest("POST", "/api/trash/"+file.ID+"/restore", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	req, _ = http.NewRequest("GET", "/api/file/"+file.ID, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "content kept in the trash", recorder.Body.String())
+}
+
+func Test_FileThumbnail_OK(t *testing.T) {
+	r := SetUpRoutes()
+	fc := SetupControllerFile()
+	jwtService := config.NewJWTService()
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+	r.GET("/api/file/:id/thumbnail", middleware.AuthenticateIfExists(jwtService), fc.GetThumbnailByID)
+
+	img := image.NewRGBA(image.Rect(0, 0, 1000, 500))
+	img.Set(0, 0, color.RGBA{R: uint8(time.Now().UnixNano()), A: 255})
+	var content bytes.Buffer
+	assert.NoError(t, png.Encode(&content, img))
+
+	file := uploadTestFile(t, r, token, "picture.png", content.String())
+
+	// the thumbnail is generated in the background
+	var recorder *httptest.ResponseRecorder
+	for i := 0; i < 50; i++ {
+		req, _ := http.NewRequest("GET", "/api/file/"+file.ID+"/thumbnail?size=128", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		recorder = httptest.NewRecorder()
+		r.ServeHTTP(recorder, req)
+		if recorder.Code != http.StatusAccepted {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "image/png", recorder.Header().Get("Content-Type"))
+
+	thumbnail, err := png.Decode(recorder.Body)
+	if assert.NoError(t, err) {
+		assert.Equal(t, image.Rect(0, 0, 128, 64), thumbnail.Bounds())
+	}
+
+	req, _ := http.NewRequest("GET", "/api/file/"+file.ID+"/thumbnail?size=100", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// the file is private
+	req, _ = http.NewRequest("GET", "/api/file/"+file.ID+"/thumbnail", nil)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	text := uploadTestFile(t, r, token, "notes.txt", "not an image")
+	req, _ = http.NewRequest("GET", "/api/file/"+text.ID+"/thumbnail", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func Test_FilePreview_OK(t *testing.T) {
+	r := SetUpRoutes()
+	r.LoadHTMLGlob("../templates/*")
+	fc := SetupControllerFile()
+	jwtService := config.NewJWTService()
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+	r.GET("/api/file/:id/preview", middleware.AuthenticateIfExists(jwtService), fc.GetPreviewByID)
+
+	file := uploadTestFile(t, r, token, "notes.md", "# Release notes\n\n<script>alert(1)</script>")
+
+	req, _ := http.NewRequest("GET", "/api/file/"+file.ID+"/preview", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "<h1>Release notes</h1>")
+	assert.NotContains(t, recorder.Body.String(), "<script>alert(1)</script>")
+
+	var table strings.Builder
+	table.WriteString("id\tname\n")
+	for i := 0; i < 60; i++ {
+		table.WriteString(strconv.Itoa(i) + "\trow " + strconv.Itoa(i) + "\n")
+	}
+	file = uploadTestFile(t, r, token, "rows.tsv", table.String())
+
+	req, _ = http.NewRequest("GET", "/api/file/"+file.ID+"/preview?page=2", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "<td>row 59</td>")
+	assert.NotContains(t, recorder.Body.String(), "<td>row 0</td>")
+	assert.Contains(t, recorder.Body.String(), "Page 2 of 2")
+
+	// the file is private
+	req, _ = http.NewRequest("GET", "/api/file/"+file.ID+"/preview", nil)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func Test_FileSharePage_OK(t *testing.T) {
+	r := SetUpRoutes()
+	r.LoadHTMLGlob("../templates/*")
+	fc := SetupControllerFile()
+	jwtService := config.NewJWTService()
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+	r.PATCH("/api/file/:id", middleware.Authenticate(jwtService), fc.UpdateByID)
+	r.GET("/share/:id", middleware.AuthenticateIfExists(jwtService), fc.GetSharePage)
+
+	file := uploadTestFile(t, r, token, "notes.txt", "<b>meeting notes</b>")
+
+	// private files are only shown to users who may view them
+	req, _ := http.NewRequest("GET", "/share/"+file.ID, nil)
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	_true := true
+	body, _ := json.Marshal(dto.FileUpdate{Shareable: &_true})
+	req, _ = http.NewRequest("PATCH", "/api/file/"+file.ID, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	// forwarded headers are ignored, any client can send them
+	req, _ = http.NewRequest("GET", "/share/"+file.ID, nil)
+	req.Host = "files.example.com"
+	req.Header.Set("X-Forwarded-Proto", "javascript")
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	page := recorder.Body.String()
+	assert.Contains(t, page, `<meta property="og:title" content="notes.txt" />`)
+	assert.Contains(t, page, `<meta property="og:url" content="http://files.example.com/share/`+file.ID+`" />`)
+	assert.Contains(t, page, "20 B · Shared by user")
+	assert.Contains(t, page, `href="/api/file/`+file.ID+`"`)
+	assert.Contains(t, page, "&lt;b&gt;meeting notes&lt;/b&gt;")
+	assert.NotContains(t, page, "<b>meeting notes</b>")
+
+	t.Setenv("PUBLIC_URL", "https://drive.example.com/")
+	req, _ = http.NewRequest("GET", "/share/"+file.ID, nil)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Contains(t, recorder.Body.String(), `<meta property="og:url" content="https://drive.example.com/share/`+file.ID+`" />`)
+
+	req, _ = http.NewRequest("GET", "/share/"+uuid.NewString(), nil)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func Test_FileArchive_OK(t *testing.T) {
+	r := SetUpRoutes()
+	setUpFolderRoutes(r)
+	fc := SetupControllerFile()
+	jwtService := config.NewJWTService()
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	r.POST("/api/file/archive", middleware.AuthenticateIfExists(jwtService), fc.CreateArchive)
+
+	first := uploadTestFile(t, r, token, "report.txt", "first report")
+	second := uploadTestFile(t, r, token, "report.txt", "second report")
+	folder := createTestFolder(t, r, token, "docs", "")
+	nested := createTestFolder(t, r, token, "drafts", folder.ID)
+	draft := uploadTestFile(t, r, token, "draft.txt", "draft")
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "PATCH", "/api/file/"+draft.ID+"/move", dto.MoveFileRequest{FolderID: nested.ID}, nil))
+
+	payload, _ := json.Marshal(dto.CreateArchiveRequest{
+		FileIDs:   []string{first.ID, second.ID},
+		FolderIDs: []string{folder.ID},
+	})
+	req, _ := http.NewRequest("POST", "/api/file/archive", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/zip", recorder.Header().Get("Content-Type"))
+
+	archive, err := zip.NewReader(bytes.NewReader(recorder.Body.Bytes()), int64(recorder.Body.Len()))
+	if assert.NoError(t, err) {
+		contents := map[string]string{}
+		for _, entry := range archive.File {
+			content, err := entry.Open()
+			assert.NoError(t, err)
+			data, _ := io.ReadAll(content)
+			content.Close()
+			contents[entry.Name] = string(data)
+		}
+		assert.Equal(t, map[string]string{
+			"report.txt":            "first report",
+			"report (1).txt":        "second report",
+			"docs/drafts/draft.txt": "draft",
+		}, contents)
+	}
+
+	// private files of the user cannot be archived by anyone else
+	req, _ = http.NewRequest("POST", "/api/file/archive", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	req, _ = http.NewRequest("POST", "/api/file/archive", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func extractTestArchive(t *testing.T, router http.Handler, token string, filename string, entries map[string]string) *httptest.ResponseRecorder {
+	archive := new(bytes.Buffer)
+	zw := zip.NewWriter(archive)
+	for name, content := range entries {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", filename)
+	assert.NoError(t, err)
+	_, err = io.Copy(part, archive)
+	assert.NoError(t, err)
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/api/file/extract", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func Test_FileExtract_OK(t *testing.T) {
+	r := SetUpRoutes()
+	setUpFolderRoutes(r)
+	fc := SetupControllerFile()
+	jwtService := config.NewJWTService()
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	r.POST("/api/file/extract", middleware.Authenticate(jwtService), fc.Extract)
+
+	recorder := extractTestArchive(t, r, token, "project.zip", map[string]string{
+		"project/README.md":   "# Project",
+		"project/src/main.go": "package main",
+	})
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	var response struct {
+		Data []dto.FileResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	if assert.Len(t, response.Data, 2) {
+		folders := map[string]string{}
+		for _, file := range response.Data {
+			if assert.NotNil(t, file.FolderID) {
+				folders[file.Filename] = *file.FolderID
+			}
+		}
+		assert.NotEqual(t, folders["README.md"], folders["main.go"])
+
+		var content dto.FolderContentResponse
+		assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/folder/"+folders["main.go"], nil, &content))
+		if assert.Len(t, content.Breadcrumbs, 2) {
+			assert.Equal(t, "project", content.Breadcrumbs[0].Name)
+			assert.Equal(t, "src", content.Breadcrumbs[1].Name)
+		}
+	}
+
+	// nothing is kept from an archive with an entry escaping its root
+	recorder = extractTestArchive(t, r, token, "evil.zip", map[string]string{
+		"fine.txt":       "fine",
+		"../../evil.txt": "evil",
+	})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	var files []dto.FileResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/file", nil, &files))
+	assert.Len(t, files, 2)
+
+	recorder = extractTestArchive(t, r, token, "project.rar", map[string]string{"a.txt": "a"})
+	assert.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+}
+
+func Test_FileList_SortFilter_OK(t *testing.T) {
+	r := SetUpRoutes()
+	fc := SetupControllerFile()
+	jwtService := config.NewJWTService()
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	r.GET("/api/file", middleware.Authenticate(jwtService), fc.GetPaginated)
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+
+	uploadTestFile(t, r, token, "b.txt", "medium content")
+	uploadTestFile(t, r, token, "a.txt", "short")
+	uploadTestFile(t, r, token, "c.pdf", "%PDF-1.4 the longest content of all")
+
+	var files []dto.FileResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/file?sort=name&order=asc", nil, &files))
+	if assert.Len(t, files, 3) {
+		assert.Equal(t, []string{"a.txt", "b.txt", "c.pdf"}, []string{files[0].Filename, files[1].Filename, files[2].Filename})
+	}
+
+	files = nil
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/file?sort=size&order=desc&mime_type=text/plain", nil, &files))
+	if assert.Len(t, files, 2) {
+		assert.Equal(t, "b.txt", files[0].Filename)
+		assert.Equal(t, "a.txt", files[1].Filename)
+	}
+
+	files = nil
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/file?min_size=6&max_size=20&shareable=false", nil, &files))
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, "b.txt", files[0].Filename)
+	}
+
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format(time.DateOnly)
+	files = nil
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/file?created_after="+tomorrow, nil, &files))
+	assert.Empty(t, files)
+
+	assert.Equal(t, http.StatusBadRequest, folderRequest(t, r, token, "GET", "/api/file?sort=password", nil, nil))
+	assert.Equal(t, http.StatusBadRequest, folderRequest(t, r, token, "GET", "/api/file?sort=name&order=sideways", nil, nil))
+	assert.Equal(t, http.StatusBadRequest, folderRequest(t, r, token, "GET", "/api/file?min_size=10&max_size=1", nil, nil))
+	assert.Equal(t, http.StatusBadRequest, folderRequest(t, r, token, "GET", "/api/file?created_before=yesterday", nil, nil))
+}
@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/controller"
+	"FP-DevOps/dto"
+	"FP-DevOps/middleware"
+	"FP-DevOps/repository"
+	"FP-DevOps/service"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func SetupControllerFolder() controller.FolderController {
+	var (
+		db               = config.SetUpDatabaseConnection()
+		jwtService       = config.NewJWTService()
+		folderService    = service.NewFolderService(repository.NewFolderRepository(db), service.NewAuthorizer(repository.NewFileGrantRepository(db), repository.NewTeamRepository(db)))
+		folderController = controller.NewFolderController(folderService, jwtService)
+	)
+
+	return folderController
+}
+
+func setUpFolderRoutes(r *gin.Engine) {
+	jwtService := config.NewJWTService()
+	fc := SetupControllerFile()
+	folderController := SetupControllerFolder()
+
+	r.GET("/api/file", middleware.Authenticate(jwtService), fc.GetPaginated)
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+	r.PATCH("/api/file/:id/move", middleware.Authenticate(jwtService), fc.MoveByID)
+
+	r.GET("/api/folder", middleware.Authenticate(jwtService), folderController.GetFolder)
+	r.GET("/api/folder/:id", middleware.Authenticate(jwtService), folderController.GetFolder)
+	r.POST("/api/folder", middleware.Authenticate(jwtService), folderController.Create)
+	r.PATCH("/api/folder/:id", middleware.Authenticate(jwtService), folderController.RenameByID)
+	r.PATCH("/api/folder/:id/move", middleware.Authenticate(jwtService), folderController.MoveByID)
+	r.DELETE("/api/folder/:id", middleware.Authenticate(jwtService), folderController.DeleteByID)
+}
+
+func folderRequest(t *testing.T, router http.Handler, token, method, url string, body any, data any) int {
+	payload, err := json.Marshal(body)
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest(method, url, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	if data != nil && recorder.Code < http.StatusBadRequest {
+		response := struct {
+			Data any `json:"data"`
+		}{Data: data}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	}
+	return recorder.Code
+}
+
+func createTestFolder(t *testing.T, router http.Handler, token, name, parentID string) dto.FolderResponse {
+	var folder dto.FolderResponse
+	code := folderRequest(t, router, token, "POST", "/api/folder", dto.CreateFolderRequest{Name: name, ParentID: parentID}, &folder)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, name, folder.Name)
+	return folder
+}
+
+func Test_Folder_Breadcrumbs_OK(t *testing.T) {
+	r := SetUpRoutes()
+	setUpFolderRoutes(r)
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	documents := createTestFolder(t, r, token, "documents", "")
+	invoices := createTestFolder(t, r, token, "invoices", documents.ID)
+	assert.Equal(t, documents.ID, *invoices.ParentID)
+
+	var content dto.FolderContentResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/folder/"+invoices.ID, nil, &content))
+	assert.Equal(t, invoices.ID, content.Folder.ID)
+	if assert.Len(t, content.Breadcrumbs, 2) {
+		assert.Equal(t, documents.ID, content.Breadcrumbs[0].ID)
+		assert.Equal(t, invoices.ID, content.Breadcrumbs[1].ID)
+	}
+
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/folder", nil, &content))
+	assert.Nil(t, content.Folder)
+	if assert.Len(t, content.Folders, 1) {
+		assert.Equal(t, documents.ID, content.Folders[0].ID)
+	}
+
+	var renamed dto.FolderResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "PATCH", "/api/folder/"+invoices.ID, dto.FolderUpdate{Name: "bills"}, &renamed))
+	assert.Equal(t, "bills", renamed.Name)
+}
+
+func Test_Folder_MoveIntoSubfolder_Conflict(t *testing.T) {
+	r := SetUpRoutes()
+	setUpFolderRoutes(r)
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	parent := createTestFolder(t, r, token, "parent", "")
+	child := createTestFolder(t, r, token, "child", parent.ID)
+
+	code := folderRequest(t, r, token, "PATCH", "/api/folder/"+parent.ID+"/move", dto.MoveFolderRequest{ParentID: child.ID}, nil)
+	assert.Equal(t, http.StatusConflict, code)
+
+	code = folderRequest(t, r, token, "PATCH", "/api/folder/"+parent.ID+"/move", dto.MoveFolderRequest{ParentID: parent.ID}, nil)
+	assert.Equal(t, http.StatusConflict, code)
+
+	var moved dto.FolderResponse
+	code = folderRequest(t, r, token, "PATCH", "/api/folder/"+child.ID+"/move", dto.MoveFolderRequest{}, &moved)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Nil(t, moved.ParentID)
+}
+
+func Test_Folder_Files_OK(t *testing.T) {
+	r := SetUpRoutes()
+	setUpFolderRoutes(r)
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	folder := createTestFolder(t, r, token, "photos", "")
+
+	// the folder is sent as a form field ahead of the file
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	assert.NoError(t, writer.WriteField("folder_id", folder.ID))
+	part, err := writer.CreateFormFile("file", "in-folder.txt")
+	assert.NoError(t, err)
+	_, err = part.Write([]byte("stored in a folder"))
+	assert.NoError(t, err)
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/api/file", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	rootFile := uploadTestFile(t, r, token, "in-root.txt", "stored at the root")
+
+	var files []dto.FileResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/file?folder_id="+folder.ID, nil, &files))
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, "in-folder.txt", files[0].Filename)
+		assert.Equal(t, folder.ID, *files[0].FolderID)
+	}
+
+	var moved dto.FileResponse
+	code := folderRequest(t, r, token, "PATCH", "/api/file/"+rootFile.ID+"/move", dto.MoveFileRequest{FolderID: folder.ID}, &moved)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, folder.ID, *moved.FolderID)
+
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/file?folder_id="+folder.ID, nil, &files))
+	assert.Len(t, files, 2)
+
+	// files in subfolders are trashed together with the folder
+	subfolder := createTestFolder(t, r, token, "2024", folder.ID)
+	nestedFile := uploadTestFile(t, r, token, "nested.txt", "stored in a subfolder")
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "PATCH", "/api/file/"+nestedFile.ID+"/move", dto.MoveFileRequest{FolderID: subfolder.ID}, nil))
+
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "DELETE", "/api/folder/"+folder.ID, nil, nil))
+	assert.Equal(t, http.StatusNotFound, folderRequest(t, r, token, "GET", "/api/folder/"+folder.ID, nil, nil))
+	assert.Equal(t, http.StatusNotFound, folderRequest(t, r, token, "GET", "/api/folder/"+subfolder.ID, nil, nil))
+
+	files = nil
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/file", nil, &files))
+	assert.Empty(t, files)
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/constants"
+	"FP-DevOps/dto"
+	"FP-DevOps/entity"
+	"FP-DevOps/middleware"
+	"FP-DevOps/repository"
+	"FP-DevOps/service"
+	"FP-DevOps/storage"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Fsck_QuarantineOrphans_OK(t *testing.T) {
+	r := SetUpRoutes()
+	fc := SetupControllerFile()
+	jwtService := config.NewJWTService()
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+	file := uploadTestFile(t, r, token, "checked.txt", "checked content "+uuid.New().String())
+
+	db := config.SetUpDatabaseConnection()
+	store := config.SetUpStorageBackend()
+	ctx := context.Background()
+
+	// a drifted reference count and an object nothing references
+	assert.NoError(t, db.Model(&entity.Blob{}).Where("checksum = ?", file.Checksum).
+		Updates(map[string]interface{}{"ref_count": 5, "updated_at": time.Now().Add(-time.Hour)}).Error)
+	orphan := "orphans/" + uuid.New().String() + ".txt"
+	assert.NoError(t, store.Put(ctx, orphan, strings.NewReader("stray"), 5))
+
+	fsckService := service.NewFsckService(repository.NewFsckRepository(db, store))
+
+	report, err := fsckService.Run(ctx, dto.FsckOptions{Mode: constants.ENUM_FSCK_MODE_DRY_RUN})
+	assert.NoError(t, err)
+	assert.Contains(t, report.OrphanObjects, orphan)
+	assert.Contains(t, report.RefCountMismatches, dto.FsckRefCount{Checksum: file.Checksum, RefCount: 5, Actual: 1})
+	_, err = store.Stat(ctx, orphan)
+	assert.NoError(t, err)
+
+	report, err = fsckService.Run(ctx, dto.FsckOptions{Mode: constants.ENUM_FSCK_MODE_QUARANTINE})
+	assert.NoError(t, err)
+	assert.Contains(t, report.OrphanObjects, orphan)
+	assert.GreaterOrEqual(t, report.Fixed, 1)
+
+	_, err = store.Stat(ctx, orphan)
+	assert.Equal(t, storage.ErrObjectNotFound, err)
+	_, err = store.Stat(ctx, constants.QUARANTINE_STORAGE_PREFIX+"/"+orphan)
+	assert.NoError(t, err)
+
+	var blob entity.Blob
+	assert.NoError(t, db.Where("checksum = ?", file.Checksum).First(&blob).Error)
+	assert.Equal(t, int64(1), blob.RefCount)
+}
+
+func Test_Fsck_InvalidMode(t *testing.T) {
+	fsckService := service.NewFsckService(repository.NewFsckRepository(config.SetUpDatabaseConnection(), config.SetUpStorageBackend()))
+
+	_, err := fsckService.Run(context.Background(), dto.FsckOptions{Mode: "repair"})
+	assert.Equal(t, dto.ErrInvalidFsckMode, err)
+}
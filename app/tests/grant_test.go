@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/controller"
+	"FP-DevOps/dto"
+	"FP-DevOps/middleware"
+	"FP-DevOps/repository"
+	"FP-DevOps/service"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func SetupControllerGrant() controller.GrantController {
+	var (
+		db              = config.SetUpDatabaseConnection()
+		fileRepo        = repository.NewFileRepository(db, config.SetUpStorageBackend())
+		jwtService      = config.NewJWTService()
+		grantService    = service.NewGrantService(repository.NewFileGrantRepository(db), fileRepo, repository.NewUserRepository(db), service.NewAuthorizer(repository.NewFileGrantRepository(db), repository.NewTeamRepository(db)))
+		grantController = controller.NewGrantController(grantService, jwtService)
+	)
+
+	return grantController
+}
+
+func setUpGrantRoutes(r *gin.Engine) {
+	jwtService := config.NewJWTService()
+	fc := SetupControllerFile()
+	grantController := SetupControllerGrant()
+
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+	r.GET("/api/file/shared", middleware.Authenticate(jwtService), grantController.GetSharedWithMe)
+	r.GET("/api/file/:id", middleware.AuthenticateIfExists(jwtService), fc.GetFileByID)
+	r.PATCH("/api/file/:id", middleware.Authenticate(jwtService), fc.UpdateByID)
+	r.DELETE("/api/file/:id", middleware.Authenticate(jwtService), fc.DeleteByID)
+	r.GET("/api/file/:id/grants", middleware.Authenticate(jwtService), grantController.GetGrants)
+	r.POST("/api/file/:id/grants", middleware.Authenticate(jwtService), grantController.Grant)
+	r.DELETE("/api/file/:id/grants/:grant", middleware.Authenticate(jwtService), grantController.RevokeByID)
+}
+
+func Test_FileGrant_Permissions(t *testing.T) {
+	r := SetUpRoutes()
+	r.LoadHTMLGlob("../templates/*")
+	setUpGrantRoutes(r)
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+	otherToken := loginTestAccount(t, "admin", "admin123")
+
+	file := uploadTestFile(t, r, token, "budget.txt", "budget")
+
+	assert.Equal(t, http.StatusForbidden, folderRequest(t, r, otherToken, "PATCH", "/api/file/"+file.ID, dto.FileUpdate{Filename: "mine.txt"}, nil))
+
+	var grant dto.FileGrantResponse
+	code := folderRequest(t, r, token, "POST", "/api/file/"+file.ID+"/grants", dto.GrantFileRequest{Username: "admin", Permission: "view"}, &grant)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "admin", grant.Username)
+
+	assert.Equal(t, http.StatusBadRequest, folderRequest(t, r, token, "POST", "/api/file/"+file.ID+"/grants", dto.GrantFileRequest{Username: "admin", Permission: "own"}, nil))
+	assert.Equal(t, http.StatusBadRequest, folderRequest(t, r, token, "POST", "/api/file/"+file.ID+"/grants", dto.GrantFileRequest{Username: "user", Permission: "view"}, nil))
+
+	// viewers can download and find the file but not change it
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, otherToken, "GET", "/api/file/"+file.ID, nil, nil))
+	assert.Equal(t, http.StatusForbidden, folderRequest(t, r, otherToken, "PATCH", "/api/file/"+file.ID, dto.FileUpdate{Filename: "mine.txt"}, nil))
+
+	var shared []dto.SharedFileResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, otherToken, "GET", "/api/file/shared", nil, &shared))
+	if assert.Len(t, shared, 1) {
+		assert.Equal(t, file.ID, shared[0].ID)
+		assert.Equal(t, "user", shared[0].Owner)
+		assert.Equal(t, "view", shared[0].Permission)
+	}
+
+	// editors can rename it, but neither share nor delete it
+	code = folderRequest(t, r, token, "POST", "/api/file/"+file.ID+"/grants", dto.GrantFileRequest{Username: "admin", Permission: "edit"}, &grant)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "edit", grant.Permission)
+
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, otherToken, "PATCH", "/api/file/"+file.ID, dto.FileUpdate{Filename: "budget-2024.txt"}, nil))
+	public := true
+	assert.Equal(t, http.StatusForbidden, folderRequest(t, r, otherToken, "PATCH", "/api/file/"+file.ID, dto.FileUpdate{Shareable: &public}, nil))
+	assert.Equal(t, http.StatusForbidden, folderRequest(t, r, otherToken, "DELETE", "/api/file/"+file.ID, nil, nil))
+	assert.Equal(t, http.StatusForbidden, folderRequest(t, r, otherToken, "GET", "/api/file/"+file.ID+"/grants", nil, nil))
+
+	var grants []dto.FileGrantResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/file/"+file.ID+"/grants", nil, &grants))
+	assert.Len(t, grants, 1)
+
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "DELETE", "/api/file/"+file.ID+"/grants/"+grant.ID, nil, nil))
+	assert.Equal(t, http.StatusBadRequest, folderRequest(t, r, otherToken, "GET", "/api/file/"+file.ID, nil, nil))
+
+	shared = nil
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, otherToken, "GET", "/api/file/shared", nil, &shared))
+	assert.Empty(t, shared)
+}
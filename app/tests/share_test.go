@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/controller"
+	"FP-DevOps/dto"
+	"FP-DevOps/middleware"
+	"FP-DevOps/repository"
+	"FP-DevOps/service"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func SetupControllerShare() controller.ShareController {
+	var (
+		db              = config.SetUpDatabaseConnection()
+		fileRepo        = repository.NewFileRepository(db, config.SetUpStorageBackend())
+		jwtService      = config.NewJWTService()
+		shareService    = service.NewShareService(repository.NewShareLinkRepository(db), fileRepo, service.NewAuthorizer(repository.NewFileGrantRepository(db), repository.NewTeamRepository(db)))
+		shareController = controller.NewShareController(shareService, jwtService)
+	)
+
+	return shareController
+}
+
+func setUpShareRoutes(r *gin.Engine) {
+	jwtService := config.NewJWTService()
+	fc := SetupControllerFile()
+	shareController := SetupControllerShare()
+
+	r.LoadHTMLGlob("../templates/*")
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+	r.GET("/api/file/:id/shares", middleware.Authenticate(jwtService), shareController.GetByFile)
+	r.POST("/api/file/:id/shares", middleware.Authenticate(jwtService), shareController.Create)
+	r.DELETE("/api/share/:id", middleware.Authenticate(jwtService), shareController.RevokeByID)
+	r.GET("/s/:token", shareController.Open)
+	r.HEAD("/s/:token", shareController.Open)
+	r.POST("/s/:token", shareController.Open)
+}
+
+func openShareLink(router http.Handler, link dto.ShareLinkResponse, password string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", link.URL, nil)
+	if password != "" {
+		req, _ = http.NewRequest("POST", link.URL, strings.NewReader(url.Values{"password": {password}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func Test_ShareLink_Password_DownloadLimit(t *testing.T) {
+	r := SetUpRoutes()
+	setUpShareRoutes(r)
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	file := uploadTestFile(t, r, token, "contract.txt", "signed contract")
+
+	maxDownloads := 1
+	var link dto.ShareLinkResponse
+	code := folderRequest(t, r, token, "POST", "/api/file/"+file.ID+"/shares", dto.CreateShareLinkRequest{Password: "secret", MaxDownloads: &maxDownloads}, &link)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.True(t, link.HasPassword)
+	assert.Equal(t, "/s/"+link.Token, link.URL)
+
+	assert.Equal(t, http.StatusUnauthorized, openShareLink(r, link, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, openShareLink(r, link, "wrong").Code)
+
+	recorder := openShareLink(r, link, "secret")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "signed contract", recorder.Body.String())
+
+	assert.Equal(t, http.StatusGone, openShareLink(r, link, "secret").Code)
+
+	var links []dto.ShareLinkResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/file/"+file.ID+"/shares", nil, &links))
+	if assert.Len(t, links, 1) {
+		assert.Equal(t, 1, links[0].Downloads)
+	}
+}
+
+func Test_ShareLink_DownloadLimit_Range(t *testing.T) {
+	r := SetUpRoutes()
+	setUpShareRoutes(r)
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	file := uploadTestFile(t, r, token, "contract.txt", "signed contract")
+
+	maxDownloads := 1
+	var link dto.ShareLinkResponse
+	code := folderRequest(t, r, token, "POST", "/api/file/"+file.ID+"/shares", dto.CreateShareLinkRequest{MaxDownloads: &maxDownloads}, &link)
+	assert.Equal(t, http.StatusCreated, code)
+
+	openRange := func(method string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, link.URL, nil)
+		req.Header.Set("Range", "bytes=-999999999")
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusPartialContent, openRange("HEAD").Code)
+
+	// a suffix range covering the whole file is a download like any other
+	recorder := openRange("GET")
+	assert.Equal(t, http.StatusPartialContent, recorder.Code)
+	assert.Equal(t, "signed contract", recorder.Body.String())
+
+	assert.Equal(t, http.StatusGone, openRange("GET").Code)
+	assert.Equal(t, http.StatusGone, openShareLink(r, link, "").Code)
+}
+
+func Test_ShareLink_Revoke(t *testing.T) {
+	r := SetUpRoutes()
+	setUpShareRoutes(r)
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	file := uploadTestFile(t, r, token, "report.txt", "quarterly report")
+
+	past := time.Now().Add(-time.Hour)
+	code := folderRequest(t, r, token, "POST", "/api/file/"+file.ID+"/shares", dto.CreateShareLinkRequest{ExpiresAt: &past}, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	week := time.Now().Add(7 * 24 * time.Hour)
+	var link dto.ShareLinkResponse
+	code = folderRequest(t, r, token, "POST", "/api/file/"+file.ID+"/shares", dto.CreateShareLinkRequest{ExpiresAt: &week}, &link)
+	assert.Equal(t, http.StatusCreated, code)
+
+	recorder := openShareLink(r, link, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "quarterly report", recorder.Body.String())
+
+	var revoked dto.ShareLinkResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "DELETE", "/api/share/"+link.ID, nil, &revoked))
+	assert.NotNil(t, revoked.RevokedAt)
+
+	assert.Equal(t, http.StatusGone, openShareLink(r, link, "").Code)
+	assert.Equal(t, http.StatusNotFound, openShareLink(r, dto.ShareLinkResponse{URL: "/s/unknown"}, "").Code)
+}
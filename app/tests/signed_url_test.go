@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/dto"
+	"FP-DevOps/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpSignedURLRoutes(r *gin.Engine) {
+	jwtService := config.NewJWTService()
+	fc := SetupControllerFile()
+
+	r.LoadHTMLGlob("../templates/*")
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+	r.GET("/api/file/:id", middleware.AuthenticateIfExists(jwtService), fc.GetFileByID)
+	r.POST("/api/file/:id/signed-url", middleware.Authenticate(jwtService), fc.SignURL)
+}
+
+func openSignedURL(router http.Handler, url string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", url, nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func Test_SignedURL_Download(t *testing.T) {
+	r := SetUpRoutes()
+	setUpSignedURLRoutes(r)
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+	otherToken := loginTestAccount(t, "admin", "admin123")
+
+	file := uploadTestFile(t, r, token, "invoice.txt", "invoice")
+
+	// private files need a login, or a signed URL
+	assert.Equal(t, http.StatusBadRequest, openSignedURL(r, "/api/file/"+file.ID).Code)
+
+	var signed dto.SignedURLResponse
+	assert.Equal(t, http.StatusCreated, folderRequest(t, r, token, "POST", "/api/file/"+file.ID+"/signed-url", dto.SignFileURLRequest{}, &signed))
+	assert.True(t, strings.HasPrefix(signed.URL, "/api/file/"+file.ID+"?"))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), signed.ExpiresAt, time.Minute)
+
+	recorder := openSignedURL(r, signed.URL)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "invoice", recorder.Body.String())
+
+	// the signature covers the file and the expiry
+	other := uploadTestFile(t, r, token, "other.txt", "other")
+	assert.Equal(t, http.StatusForbidden, openSignedURL(r, strings.Replace(signed.URL, file.ID, other.ID, 1)).Code)
+	assert.Equal(t, http.StatusForbidden, openSignedURL(r, strings.Replace(signed.URL, "expires=", "expires=1", 1)).Code)
+
+	assert.Equal(t, http.StatusForbidden, folderRequest(t, r, otherToken, "POST", "/api/file/"+file.ID+"/signed-url", dto.SignFileURLRequest{}, nil))
+	assert.Equal(t, http.StatusBadRequest, folderRequest(t, r, token, "POST", "/api/file/"+file.ID+"/signed-url", dto.SignFileURLRequest{ExpiresIn: 8 * 24 * 60 * 60}, nil))
+
+	assert.Equal(t, http.StatusCreated, folderRequest(t, r, token, "POST", "/api/file/"+file.ID+"/signed-url", dto.SignFileURLRequest{ExpiresIn: 1}, &signed))
+	time.Sleep(2 * time.Second)
+	assert.Equal(t, http.StatusGone, openSignedURL(r, signed.URL).Code)
+}
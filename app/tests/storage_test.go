@@ -0,0 +1,275 @@
+package tests
+
+import (
+	"FP-DevOps/storage"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func assertStorageRoundTrip(t *testing.T, backend storage.Backend) {
+	ctx := context.Background()
+	prefix := "test-" + uuid.New().String() + "/"
+	key := prefix + "file.txt"
+
+	content := []byte("storage backend content")
+	err := backend.Put(ctx, key, bytes.NewReader(content), int64(len(content)))
+	assert.NoError(t, err)
+
+	_, err = backend.Stat(ctx, key)
+	assert.NoError(t, err)
+
+	object, err := storage.Open(ctx, backend, key)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), object.Size())
+	object.Close()
+
+	reader, err := backend.Get(ctx, key)
+	assert.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, content, data)
+
+	reader, err = backend.GetRange(ctx, key, 8, 7)
+	assert.NoError(t, err)
+	data, err = io.ReadAll(reader)
+	reader.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("backend"), data)
+
+	objects, err := backend.List(ctx, prefix)
+	assert.NoError(t, err)
+	assert.Len(t, objects, 1)
+	assert.Equal(t, key, objects[0].Key)
+
+	assert.NoError(t, backend.Delete(ctx, key))
+
+	_, err = backend.Get(ctx, key)
+	assert.Equal(t, storage.ErrObjectNotFound, err)
+}
+
+func Test_LocalStorage_RoundTrip_OK(t *testing.T) {
+	assertStorageRoundTrip(t, storage.NewLocalBackend(t.TempDir()))
+}
+
+func Test_LocalStorage_InvalidKey(t *testing.T) {
+	ctx := context.Background()
+	backend := storage.NewLocalBackend(t.TempDir())
+
+	err := backend.Put(ctx, "../escape.txt", bytes.NewReader(nil), 0)
+	assert.Equal(t, storage.ErrInvalidKey, err)
+}
+
+func Test_LocalStorage_List_Prefix_OK(t *testing.T) {
+	ctx := context.Background()
+	backend := storage.NewLocalBackend(t.TempDir())
+
+	for _, key := range []string{"a/one.txt", "a/two.txt", "ab/three.txt", "b/four.txt"} {
+		assert.NoError(t, backend.Put(ctx, key, bytes.NewReader(nil), 0))
+	}
+
+	objects, err := backend.List(ctx, "a/")
+	assert.NoError(t, err)
+	assert.Len(t, objects, 2)
+
+	objects, err = backend.List(ctx, "a")
+	assert.NoError(t, err)
+	assert.Len(t, objects, 3)
+
+	objects, err = backend.List(ctx, "missing/")
+	assert.NoError(t, err)
+	assert.Empty(t, objects)
+}
+
+func Test_StorageObject_Seek_OK(t *testing.T) {
+	ctx := context.Background()
+	backend := storage.NewLocalBackend(t.TempDir())
+
+	content := []byte("0123456789")
+	assert.NoError(t, backend.Put(ctx, "object.txt", bytes.NewReader(content), int64(len(content))))
+
+	object, err := storage.Open(ctx, backend, "object.txt")
+	assert.NoError(t, err)
+	defer object.Close()
+	assert.Equal(t, int64(len(content)), object.Size())
+
+	offset, err := object.Seek(-4, io.SeekEnd)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), offset)
+
+	data, err := io.ReadAll(object)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("6789"), data)
+
+	_, err = object.Seek(2, io.SeekStart)
+	assert.NoError(t, err)
+	buf := make([]byte, 3)
+	_, err = io.ReadFull(object, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("234"), buf)
+
+	_, err = storage.Open(ctx, backend, "missing.txt")
+	assert.Equal(t, storage.ErrObjectNotFound, err)
+}
+
+func newEncryptedTestBackend(t *testing.T, root string) storage.Backend {
+	backend, err := storage.NewEncryptedBackend(storage.NewLocalBackend(root), bytes.Repeat([]byte{7}, 32))
+	assert.NoError(t, err)
+	return backend
+}
+
+func Test_EncryptedStorage_RoundTrip_OK(t *testing.T) {
+	assertStorageRoundTrip(t, newEncryptedTestBackend(t, t.TempDir()))
+}
+
+func Test_EncryptedStorage_Range_OK(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	backend := newEncryptedTestBackend(t, root)
+
+	content := make([]byte, 200<<10+123)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	assert.NoError(t, backend.Put(ctx, "large.bin", bytes.NewReader(content), -1))
+
+	stored, err := os.ReadFile(filepath.Join(root, "large.bin"))
+	assert.NoError(t, err)
+	assert.False(t, bytes.Contains(stored, content[:64]))
+
+	object, err := storage.Open(ctx, backend, "large.bin")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), object.Size())
+	_, err = object.Seek(100000, io.SeekStart)
+	assert.NoError(t, err)
+	data, err := io.ReadAll(object)
+	object.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, content[100000:], data)
+
+	for _, r := range [][2]int64{{0, 10}, {65530, 20}, {131072, 65536}, {150000, -1}} {
+		reader, err := backend.GetRange(ctx, "large.bin", r[0], r[1])
+		assert.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		assert.NoError(t, err)
+
+		end := int64(len(content))
+		if r[1] >= 0 {
+			end = r[0] + r[1]
+		}
+		assert.Equal(t, content[r[0]:end], data)
+	}
+}
+
+func Test_EncryptedStorage_Tampered(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	backend := newEncryptedTestBackend(t, root)
+
+	assert.NoError(t, backend.Put(ctx, "file.txt", bytes.NewBufferString("secret content"), -1))
+
+	filePath := filepath.Join(root, "file.txt")
+	stored, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+	stored[len(stored)-1] ^= 1
+	assert.NoError(t, os.WriteFile(filePath, stored, 0644))
+
+	reader, err := backend.Get(ctx, "file.txt")
+	assert.NoError(t, err)
+	_, err = io.ReadAll(reader)
+	reader.Close()
+	assert.Equal(t, storage.ErrObjectCorrupted, err)
+}
+
+func Test_EncryptedStorage_EncryptObject_OK(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	plain := storage.NewLocalBackend(root)
+	backend := newEncryptedTestBackend(t, root)
+
+	content := []byte("written before encryption was enabled")
+	assert.NoError(t, plain.Put(ctx, "legacy.txt", bytes.NewReader(content), int64(len(content))))
+
+	reader, err := backend.Get(ctx, "legacy.txt")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	assert.Equal(t, content, data)
+
+	encrypted, err := backend.(storage.Encrypter).EncryptObject(ctx, "legacy.txt")
+	assert.NoError(t, err)
+	assert.True(t, encrypted)
+
+	encrypted, err = backend.(storage.Encrypter).EncryptObject(ctx, "legacy.txt")
+	assert.NoError(t, err)
+	assert.False(t, encrypted)
+
+	stored, err := os.ReadFile(filepath.Join(root, "legacy.txt"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, content, stored)
+
+	reader, err = backend.Get(ctx, "legacy.txt")
+	assert.NoError(t, err)
+	data, _ = io.ReadAll(reader)
+	reader.Close()
+	assert.Equal(t, content, data)
+}
+
+func Test_S3Storage_RoundTrip_OK(t *testing.T) {
+	if os.Getenv("S3_ENDPOINT") == "" {
+		t.Skip("S3_ENDPOINT not set")
+	}
+
+	backend, err := storage.NewS3Backend(storage.S3Config{
+		Endpoint:        os.Getenv("S3_ENDPOINT"),
+		Region:          os.Getenv("S3_REGION"),
+		Bucket:          os.Getenv("S3_BUCKET"),
+		AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		UsePathStyle:    true,
+	})
+	assert.NoError(t, err)
+
+	assertStorageRoundTrip(t, backend)
+}
+
+func Test_AzureBlobStorage_RoundTrip_OK(t *testing.T) {
+	if os.Getenv("AZURE_STORAGE_ENDPOINT") == "" {
+		t.Skip("AZURE_STORAGE_ENDPOINT not set")
+	}
+
+	backend, err := storage.NewAzureBlobBackend(storage.AzureBlobConfig{
+		Endpoint:        os.Getenv("AZURE_STORAGE_ENDPOINT"),
+		Account:         os.Getenv("AZURE_STORAGE_ACCOUNT"),
+		Container:       os.Getenv("AZURE_STORAGE_CONTAINER"),
+		AccountKey:      os.Getenv("AZURE_STORAGE_KEY"),
+		CreateContainer: true,
+	})
+	assert.NoError(t, err)
+
+	assertStorageRoundTrip(t, backend)
+}
+
+func Test_StorageObject_LargeSequentialRead_OK(t *testing.T) {
+	ctx := context.Background()
+	backend := storage.NewLocalBackend(t.TempDir())
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64<<10)
+	assert.NoError(t, backend.Put(ctx, "large.bin", bytes.NewReader(content), int64(len(content))))
+
+	object, err := storage.Open(ctx, backend, "large.bin")
+	assert.NoError(t, err)
+	defer object.Close()
+
+	data, err := io.ReadAll(object)
+	assert.NoError(t, err)
+	assert.Equal(t, content, data)
+}
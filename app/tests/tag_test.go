@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/controller"
+	"FP-DevOps/dto"
+	"FP-DevOps/middleware"
+	"FP-DevOps/repository"
+	"FP-DevOps/service"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func SetupControllerTag() controller.TagController {
+	var (
+		db            = config.SetUpDatabaseConnection()
+		fileRepo      = repository.NewFileRepository(db, config.SetUpStorageBackend())
+		jwtService    = config.NewJWTService()
+		tagService    = service.NewTagService(repository.NewTagRepository(db), fileRepo, service.NewAuthorizer(repository.NewFileGrantRepository(db), repository.NewTeamRepository(db)))
+		tagController = controller.NewTagController(tagService, jwtService)
+	)
+
+	return tagController
+}
+
+func setUpTagRoutes(r *gin.Engine) {
+	jwtService := config.NewJWTService()
+	fc := SetupControllerFile()
+	tagController := SetupControllerTag()
+
+	r.GET("/api/file", middleware.Authenticate(jwtService), fc.GetPaginated)
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+	r.POST("/api/file/:id/tags", middleware.Authenticate(jwtService), tagController.AddToFile)
+	r.DELETE("/api/file/:id/tags/:tag", middleware.Authenticate(jwtService), tagController.RemoveFromFile)
+
+	r.GET("/api/tag", middleware.Authenticate(jwtService), tagController.GetTags)
+	r.DELETE("/api/tag/:id", middleware.Authenticate(jwtService), tagController.DeleteByID)
+}
+
+func Test_Tag_Filter_OK(t *testing.T) {
+	r := SetUpRoutes()
+	setUpTagRoutes(r)
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	invoice := uploadTestFile(t, r, token, "invoice.pdf", "invoice")
+	uploadTestFile(t, r, token, "notes.txt", "notes")
+
+	var tags dto.FileTagsResponse
+	code := folderRequest(t, r, token, "POST", "/api/file/"+invoice.ID+"/tags", dto.AddTagsRequest{Tags: []string{"Finance", "2024 ", "finance"}}, &tags)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"2024", "finance"}, tags.Tags)
+
+	code = folderRequest(t, r, token, "POST", "/api/file/"+invoice.ID+"/tags", dto.AddTagsRequest{Tags: []string{"a/b"}}, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	var files []dto.FileResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/file?tag=Finance", nil, &files))
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, invoice.ID, files[0].ID)
+		assert.Equal(t, []string{"2024", "finance"}, files[0].Tags)
+	}
+
+	var counts []dto.TagResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/tag", nil, &counts))
+	if assert.Len(t, counts, 2) {
+		assert.Equal(t, "2024", counts[0].Name)
+		assert.Equal(t, int64(1), counts[0].Count)
+	}
+
+	code = folderRequest(t, r, token, "DELETE", "/api/file/"+invoice.ID+"/tags/finance", nil, &tags)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"2024"}, tags.Tags)
+
+	files = nil
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/file?tag=finance", nil, &files))
+	assert.Empty(t, files)
+
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "DELETE", "/api/tag/"+counts[0].ID, nil, nil))
+	counts = nil
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/tag", nil, &counts))
+	if assert.Len(t, counts, 1) {
+		assert.Equal(t, "finance", counts[0].Name)
+		assert.Equal(t, int64(0), counts[0].Count)
+	}
+}
@@ -0,0 +1,255 @@
+package tests
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/controller"
+	"FP-DevOps/dto"
+	"FP-DevOps/middleware"
+	"FP-DevOps/repository"
+	"FP-DevOps/service"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func SetupControllerTeam() controller.TeamController {
+	var (
+		db             = config.SetUpDatabaseConnection()
+		teamRepo       = repository.NewTeamRepository(db)
+		jwtService     = config.NewJWTService()
+		teamService    = service.NewTeamService(teamRepo, repository.NewUserRepository(db), service.NewAuthorizer(repository.NewFileGrantRepository(db), teamRepo))
+		teamController = controller.NewTeamController(teamService, jwtService)
+	)
+
+	return teamController
+}
+
+func setUpTeamRoutes(r *gin.Engine) {
+	jwtService := config.NewJWTService()
+	fc := SetupControllerFile()
+	folderController := SetupControllerFolder()
+	teamController := SetupControllerTeam()
+
+	r.GET("/api/file", middleware.Authenticate(jwtService), fc.GetPaginated)
+	r.POST("/api/file", middleware.Authenticate(jwtService), fc.Create)
+	r.GET("/api/file/:id", middleware.AuthenticateIfExists(jwtService), fc.GetFileByID)
+	r.PATCH("/api/file/:id", middleware.Authenticate(jwtService), fc.UpdateByID)
+	r.PATCH("/api/file/:id/move", middleware.Authenticate(jwtService), fc.MoveByID)
+	r.DELETE("/api/file/:id", middleware.Authenticate(jwtService), fc.DeleteByID)
+	r.POST("/api/folder", middleware.Authenticate(jwtService), folderController.Create)
+
+	r.GET("/api/team", middleware.Authenticate(jwtService), teamController.GetTeams)
+	r.POST("/api/team", middleware.Authenticate(jwtService), teamController.Create)
+	r.GET("/api/team/invitations", middleware.Authenticate(jwtService), teamController.GetInvitations)
+	r.POST("/api/team/invitations/:id/accept", middleware.Authenticate(jwtService), teamController.AcceptInvitation)
+	r.DELETE("/api/team/invitations/:id", middleware.Authenticate(jwtService), teamController.DeclineInvitation)
+	r.GET("/api/team/:id", middleware.Authenticate(jwtService), teamController.GetTeam)
+	r.DELETE("/api/team/:id", middleware.Authenticate(jwtService), teamController.DeleteByID)
+	r.POST("/api/team/:id/invitations", middleware.Authenticate(jwtService), teamController.Invite)
+	r.PATCH("/api/team/:id/members/:user", middleware.Authenticate(jwtService), teamController.UpdateMember)
+	r.DELETE("/api/team/:id/members/:user", middleware.Authenticate(jwtService), teamController.RemoveMember)
+	r.GET("/api/team/:id/files", middleware.Authenticate(jwtService), fc.GetTeamFiles)
+	r.POST("/api/team/:id/files", middleware.Authenticate(jwtService), fc.CreateTeamFiles)
+	r.GET("/api/team/:id/folders", middleware.Authenticate(jwtService), folderController.GetTeamRoot)
+}
+
+func uploadTeamFile(t *testing.T, router http.Handler, token, teamID, filename, content string, file *dto.FileResponse) int {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", filename)
+	assert.NoError(t, err)
+	part.Write([]byte(content))
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/api/team/"+teamID+"/files", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	if file != nil && recorder.Code == http.StatusCreated {
+		response := struct {
+			Data *dto.FileResponse `json:"data"`
+		}{Data: file}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	}
+	return recorder.Code
+}
+
+// joinTestTeam invites the user of token to the team and accepts the
+// invitation on their behalf.
+func joinTestTeam(t *testing.T, router http.Handler, ownerToken, token, teamID, username, role string) {
+	var invitation dto.TeamInvitationResponse
+	code := folderRequest(t, router, ownerToken, "POST", "/api/team/"+teamID+"/invitations", dto.InviteTeamMemberRequest{Username: username, Role: role}, &invitation)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, http.StatusOK, folderRequest(t, router, token, "POST", "/api/team/invitations/"+invitation.ID+"/accept", nil, nil))
+}
+
+func Test_Team_Membership(t *testing.T) {
+	r := SetUpRoutes()
+	setUpTeamRoutes(r)
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+	otherToken := loginTestAccount(t, "admin", "admin123")
+
+	var team dto.TeamResponse
+	assert.Equal(t, http.StatusCreated, folderRequest(t, r, token, "POST", "/api/team", dto.CreateTeamRequest{Name: "Design"}, &team))
+	assert.Equal(t, "owner", team.Role)
+	assert.Equal(t, http.StatusBadRequest, folderRequest(t, r, token, "POST", "/api/team", dto.CreateTeamRequest{Name: "  "}, nil))
+
+	// teams of others are not found until their invitation is accepted
+	assert.Equal(t, http.StatusNotFound, folderRequest(t, r, otherToken, "GET", "/api/team/"+team.ID, nil, nil))
+	assert.Equal(t, http.StatusNotFound, folderRequest(t, r, token, "POST", "/api/team/"+team.ID+"/invitations", dto.InviteTeamMemberRequest{Username: "nobody", Role: "member"}, nil))
+	assert.Equal(t, http.StatusBadRequest, folderRequest(t, r, token, "POST", "/api/team/"+team.ID+"/invitations", dto.InviteTeamMemberRequest{Username: "admin", Role: "boss"}, nil))
+
+	var invitation dto.TeamInvitationResponse
+	assert.Equal(t, http.StatusCreated, folderRequest(t, r, token, "POST", "/api/team/"+team.ID+"/invitations", dto.InviteTeamMemberRequest{Username: "admin", Role: "viewer"}, &invitation))
+	assert.Equal(t, "Design", invitation.TeamName)
+	assert.Equal(t, "user", invitation.InvitedBy)
+
+	var invitations []dto.TeamInvitationResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, otherToken, "GET", "/api/team/invitations", nil, &invitations))
+	assert.Len(t, invitations, 1)
+	assert.Equal(t, http.StatusNotFound, folderRequest(t, r, token, "POST", "/api/team/invitations/"+invitation.ID+"/accept", nil, nil))
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, otherToken, "POST", "/api/team/invitations/"+invitation.ID+"/accept", nil, nil))
+	assert.Equal(t, http.StatusConflict, folderRequest(t, r, token, "POST", "/api/team/"+team.ID+"/invitations", dto.InviteTeamMemberRequest{Username: "admin", Role: "member"}, nil))
+
+	var detail dto.TeamDetailResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, otherToken, "GET", "/api/team/"+team.ID, nil, &detail))
+	assert.Equal(t, "viewer", detail.Role)
+	if assert.Len(t, detail.Members, 2) {
+		assert.Equal(t, "admin", detail.Members[0].Username)
+		assert.Equal(t, "user", detail.Members[1].Username)
+	}
+	ownerID := detail.Members[1].UserID
+	viewerID := detail.Members[0].UserID
+
+	// viewers manage nobody, and the only owner cannot leave
+	assert.Equal(t, http.StatusForbidden, folderRequest(t, r, otherToken, "PATCH", "/api/team/"+team.ID+"/members/"+ownerID, dto.UpdateTeamMemberRequest{Role: "viewer"}, nil))
+	assert.Equal(t, http.StatusConflict, folderRequest(t, r, token, "DELETE", "/api/team/"+team.ID+"/members/"+ownerID, nil, nil))
+
+	var member dto.TeamMemberResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "PATCH", "/api/team/"+team.ID+"/members/"+viewerID, dto.UpdateTeamMemberRequest{Role: "owner"}, &member))
+	assert.Equal(t, "owner", member.Role)
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "DELETE", "/api/team/"+team.ID+"/members/"+ownerID, nil, nil))
+
+	var teams []dto.TeamResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/team", nil, &teams))
+	assert.Len(t, teams, 0)
+
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, otherToken, "DELETE", "/api/team/"+team.ID, nil, nil))
+	assert.Equal(t, http.StatusNotFound, folderRequest(t, r, otherToken, "GET", "/api/team/"+team.ID, nil, nil))
+}
+
+func Test_Team_Files(t *testing.T) {
+	r := SetUpRoutes()
+	setUpTeamRoutes(r)
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+	otherToken := loginTestAccount(t, "admin", "admin123")
+
+	var team dto.TeamResponse
+	assert.Equal(t, http.StatusCreated, folderRequest(t, r, token, "POST", "/api/team", dto.CreateTeamRequest{Name: "Design"}, &team))
+	joinTestTeam(t, r, token, otherToken, team.ID, "admin", "viewer")
+
+	var file dto.FileResponse
+	assert.Equal(t, http.StatusCreated, uploadTeamFile(t, r, token, team.ID, "logo.txt", "logo", &file))
+	if assert.NotNil(t, file.TeamID) {
+		assert.Equal(t, team.ID, *file.TeamID)
+	}
+	assert.Equal(t, http.StatusForbidden, uploadTeamFile(t, r, otherToken, team.ID, "mine.txt", "mine", nil))
+
+	// team files are listed for the team, not with the uploader's own files
+	var files []dto.FileResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, otherToken, "GET", "/api/team/"+team.ID+"/files", nil, &files))
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, file.ID, files[0].ID)
+	}
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/file", nil, &files))
+	assert.Len(t, files, 0)
+
+	// viewers read the team's files, members also change them and admins
+	// delete them
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, otherToken, "GET", "/api/file/"+file.ID, nil, nil))
+	assert.Equal(t, http.StatusForbidden, folderRequest(t, r, otherToken, "PATCH", "/api/file/"+file.ID, dto.FileUpdate{Filename: "logo-v2.txt"}, nil))
+
+	var detail dto.TeamDetailResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, otherToken, "GET", "/api/team/"+team.ID, nil, &detail))
+	assert.Equal(t, "viewer", detail.Role)
+	var memberID string
+	for _, member := range detail.Members {
+		if member.Username == "admin" {
+			memberID = member.UserID
+		}
+	}
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "PATCH", "/api/team/"+team.ID+"/members/"+memberID, dto.UpdateTeamMemberRequest{Role: "member"}, nil))
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, otherToken, "PATCH", "/api/file/"+file.ID, dto.FileUpdate{Filename: "logo-v2.txt"}, nil))
+	assert.Equal(t, http.StatusForbidden, folderRequest(t, r, otherToken, "DELETE", "/api/file/"+file.ID, nil, nil))
+
+	// folders of the team hold its files only
+	var folder dto.FolderResponse
+	assert.Equal(t, http.StatusCreated, folderRequest(t, r, otherToken, "POST", "/api/folder", dto.CreateFolderRequest{Name: "Logos", TeamID: team.ID}, &folder))
+	if assert.NotNil(t, folder.TeamID) {
+		assert.Equal(t, team.ID, *folder.TeamID)
+	}
+	var content dto.FolderContentResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/team/"+team.ID+"/folders", nil, &content))
+	assert.Len(t, content.Folders, 1)
+
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, otherToken, "PATCH", "/api/file/"+file.ID+"/move", dto.MoveFileRequest{FolderID: folder.ID}, nil))
+	personal := uploadTestFile(t, r, token, "notes.txt", "notes")
+	assert.Equal(t, http.StatusConflict, folderRequest(t, r, token, "PATCH", "/api/file/"+personal.ID+"/move", dto.MoveFileRequest{FolderID: folder.ID}, nil))
+
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/file?folder_id="+folder.ID, nil, &files))
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, file.ID, files[0].ID)
+	}
+
+	// teams holding files are kept, files in the trash included
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "DELETE", "/api/file/"+file.ID, nil, nil))
+	assert.Equal(t, http.StatusConflict, folderRequest(t, r, token, "DELETE", "/api/team/"+team.ID, nil, nil))
+}
+
+func Test_Team_LastOwner_Concurrent(t *testing.T) {
+	r := SetUpRoutes()
+	setUpTeamRoutes(r)
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+	otherToken := loginTestAccount(t, "admin", "admin123")
+
+	var team dto.TeamResponse
+	assert.Equal(t, http.StatusCreated, folderRequest(t, r, token, "POST", "/api/team", dto.CreateTeamRequest{Name: "Design"}, &team))
+	joinTestTeam(t, r, token, otherToken, team.ID, "admin", "owner")
+
+	var detail dto.TeamDetailResponse
+	assert.Equal(t, http.StatusOK, folderRequest(t, r, token, "GET", "/api/team/"+team.ID, nil, &detail))
+	if !assert.Len(t, detail.Members, 2) {
+		return
+	}
+
+	// both owners step down at once, only one of them may
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	for i, member := range detail.Members {
+		memberToken := token
+		if member.Username == "admin" {
+			memberToken = otherToken
+		}
+		wg.Add(1)
+		go func(i int, token, memberID string) {
+			defer wg.Done()
+			codes[i] = folderRequest(t, r, token, "PATCH", "/api/team/"+team.ID+"/members/"+memberID, dto.UpdateTeamMemberRequest{Role: "viewer"}, nil)
+		}(i, memberToken, member.UserID)
+	}
+	wg.Wait()
+
+	assert.ElementsMatch(t, []int{http.StatusOK, http.StatusConflict}, codes)
+}
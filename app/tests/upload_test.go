@@ -0,0 +1,224 @@
+package tests
+
+import (
+	"FP-DevOps/config"
+	"FP-DevOps/constants"
+	"FP-DevOps/controller"
+	"FP-DevOps/middleware"
+	"FP-DevOps/repository"
+	"FP-DevOps/service"
+	"FP-DevOps/storage"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func SetupControllerUpload() controller.UploadController {
+	var (
+		db               = config.SetUpDatabaseConnection()
+		store            = config.SetUpStorageBackend()
+		jwtService       = config.NewJWTService()
+		fileRepo         = repository.NewFileRepository(db, store)
+		fileService      = service.NewFileService(fileRepo, repository.NewUserRepository(db), repository.NewFolderRepository(db), service.NewAuthorizer(repository.NewFileGrantRepository(db), repository.NewTeamRepository(db)), service.NewThumbnailService(repository.NewThumbnailRepository(store), fileRepo))
+		uploadService    = service.NewUploadService(repository.NewUploadRepository(db, store), fileService)
+		uploadController = controller.NewUploadController(uploadService, jwtService)
+	)
+
+	return uploadController
+}
+
+func setUpUploadRoutes(r *gin.Engine) {
+	jwtService := config.NewJWTService()
+	uc := SetupControllerUpload()
+	fc := SetupControllerFile()
+
+	r.POST("/api/file/upload", middleware.Authenticate(jwtService), uc.Create)
+	r.HEAD("/api/file/upload/:id", middleware.Authenticate(jwtService), uc.GetOffset)
+	r.PATCH("/api/file/upload/:id", middleware.Authenticate(jwtService), uc.Append)
+	r.GET("/api/file/:id", middleware.AuthenticateIfExists(jwtService), fc.GetFileByID)
+}
+
+func createTestUpload(t *testing.T, r http.Handler, token, filename string, length int) string {
+	req, _ := http.NewRequest("POST", "/api/file/upload", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Tus-Resumable", constants.TUS_VERSION)
+	req.Header.Set("Upload-Length", strconv.Itoa(length))
+	req.Header.Set("Upload-Metadata", "filename "+base64.StdEncoding.EncodeToString([]byte(filename)))
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.NotEmpty(t, recorder.Header().Get("Upload-Expires"))
+	location := recorder.Header().Get("Location")
+	assert.NotEmpty(t, location)
+
+	return location
+}
+
+func patchTestUpload(r http.Handler, token, location string, offset int, chunk string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("PATCH", location, bytes.NewBufferString(chunk))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Tus-Resumable", constants.TUS_VERSION)
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", strconv.Itoa(offset))
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func Test_ResumableUpload_OK(t *testing.T) {
+	r := SetUpRoutes()
+	setUpUploadRoutes(r)
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	content := "resumable upload content"
+	location := createTestUpload(t, r, token, "resumable.txt", len(content))
+
+	recorder := patchTestUpload(r, token, location, 0, content[:10])
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "10", recorder.Header().Get("Upload-Offset"))
+
+	req, _ := http.NewRequest("HEAD", location, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Tus-Resumable", constants.TUS_VERSION)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "10", recorder.Header().Get("Upload-Offset"))
+	assert.Equal(t, strconv.Itoa(len(content)), recorder.Header().Get("Upload-Length"))
+
+	recorder = patchTestUpload(r, token, location, 10, content[10:])
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	fileID := recorder.Header().Get("File-Id")
+	assert.NotEmpty(t, fileID)
+
+	req, _ = http.NewRequest("GET", "/api/file/"+fileID, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, content, recorder.Body.String())
+}
+
+type droppedConnection struct {
+	data []byte
+}
+
+func (r *droppedConnection) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func Test_ResumableUpload_DroppedConnection_KeepsReceivedBytes(t *testing.T) {
+	r := SetUpRoutes()
+	setUpUploadRoutes(r)
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	content := "content sent over a flaky connection"
+	location := createTestUpload(t, r, token, "flaky.txt", len(content))
+
+	req, _ := http.NewRequest("PATCH", location, &droppedConnection{data: []byte(content[:12])})
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Tus-Resumable", constants.TUS_VERSION)
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", "0")
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.NotEqual(t, http.StatusNoContent, recorder.Code)
+
+	req, _ = http.NewRequest("HEAD", location, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Tus-Resumable", constants.TUS_VERSION)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, "12", recorder.Header().Get("Upload-Offset"))
+
+	recorder = patchTestUpload(r, token, location, 12, content[12:])
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.NotEmpty(t, recorder.Header().Get("File-Id"))
+}
+
+func Test_ResumableUpload_OffsetMismatch(t *testing.T) {
+	r := SetUpRoutes()
+	setUpUploadRoutes(r)
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	location := createTestUpload(t, r, token, "mismatch.txt", 10)
+
+	recorder := patchTestUpload(r, token, location, 5, "01234")
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	recorder = patchTestUpload(r, token, "/api/file/upload/not-an-id", 0, "01234")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func Test_ResumableUpload_UnsupportedVersion(t *testing.T) {
+	r := SetUpRoutes()
+	setUpUploadRoutes(r)
+	CleanUpTestUsers()
+	token := loginTestAccount(t, "user", "user123")
+
+	req, _ := http.NewRequest("POST", "/api/file/upload", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Tus-Resumable", "0.2.2")
+	req.Header.Set("Upload-Length", "10")
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+	assert.Equal(t, constants.TUS_VERSION, recorder.Header().Get("Tus-Version"))
+}
+
+func Test_UploadChunks_ReadInOrder_OK(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewUploadRepository(nil, storage.NewLocalBackend(t.TempDir()))
+
+	chunks := []string{"first ", "second ", "third"}
+	offset := 0
+	for _, chunk := range chunks {
+		_, err := repo.WriteChunk(ctx, "upload", int64(offset), bytes.NewBufferString(chunk), int64(len(chunk)))
+		assert.NoError(t, err)
+		offset += len(chunk)
+	}
+
+	// chunks left behind by requests that lost the race for their offset
+	for offset, chunk := range map[int64]string{0: "first sec", 6: "sec"} {
+		_, err := repo.WriteChunk(ctx, "upload", offset, bytes.NewBufferString(chunk), int64(len(chunk)))
+		assert.NoError(t, err)
+	}
+
+	reader, err := repo.ReadChunks(ctx, "upload")
+	assert.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "first second third", string(data))
+
+	assert.NoError(t, repo.DeleteChunks(ctx, "upload"))
+	reader, err = repo.ReadChunks(ctx, "upload")
+	assert.NoError(t, err)
+	data, err = io.ReadAll(reader)
+	reader.Close()
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
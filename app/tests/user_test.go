@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"FP-DevOps/config"
+	"FP-DevOps/constants"
 	"FP-DevOps/controller"
 	"FP-DevOps/dto"
 	"FP-DevOps/entity"
@@ -170,10 +171,14 @@ func Test_Me_OK(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	type Resp struct {
-		Data entity.User `json:"data"`
+		Data dto.UserResponse `json:"data"`
 	}
 	var resp Resp
 	err := json.Unmarshal(w.Body.Bytes(), &resp)
 	assert.Nil(t, err)
 	assert.Equal(t, "admin", resp.Data.Username)
+	if assert.NotNil(t, resp.Data.Storage) {
+		assert.Equal(t, int64(0), resp.Data.Storage.Used)
+		assert.Equal(t, int64(constants.DEFAULT_STORAGE_QUOTA_MB*constants.MB), resp.Data.Storage.Limit)
+	}
 }
@@ -0,0 +1,18 @@
+package utils
+
+import "fmt"
+
+// FormatSize formats a size in bytes for people, e.g. 1.5 MB.
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value, exp := float64(size)/unit, 0
+	for value >= unit && exp < 4 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", value, "KMGTP"[exp])
+}
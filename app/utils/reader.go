@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+)
+
+// HashingReader computes the size and SHA-256 checksum of everything read
+// through it. When limit is positive, reading more than limit bytes fails
+// with limitErr.
+type HashingReader struct {
+	reader   io.Reader
+	hash     hash.Hash
+	size     int64
+	limit    int64
+	limitErr error
+}
+
+func NewHashingReader(r io.Reader, limit int64, limitErr error) *HashingReader {
+	return &HashingReader{
+		reader:   r,
+		hash:     sha256.New(),
+		limit:    limit,
+		limitErr: limitErr,
+	}
+}
+
+func (r *HashingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.hash.Write(p[:n])
+	r.size += int64(n)
+
+	if r.limit > 0 && r.size > r.limit {
+		return n, r.limitErr
+	}
+	return n, err
+}
+
+func (r *HashingReader) Size() int64 {
+	return r.size
+}
+
+func (r *HashingReader) Checksum() string {
+	return hex.EncodeToString(r.hash.Sum(nil))
+}
@@ -8,4 +8,17 @@ output "public_ip_address_prod" {
 
 output "public_ip_address_dev" {
   value = azurerm_linux_virtual_machine.pso_vm_dev.public_ip_address
-}
\ No newline at end of file
+}
+
+output "storage_account_name" {
+  value = azurerm_storage_account.pso_sg.name
+}
+
+output "storage_container_name" {
+  value = azurerm_storage_container.pso_files.name
+}
+
+output "storage_account_primary_key" {
+  value     = azurerm_storage_account.pso_sg.primary_access_key
+  sensitive = true
+}
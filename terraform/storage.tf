@@ -13,4 +13,10 @@ resource "azurerm_storage_account" "pso_sg" {
   resource_group_name      = azurerm_resource_group.pso_rg.name
   account_tier             = "Standard"
   account_replication_type = "LRS"
-}
\ No newline at end of file
+}
+
+resource "azurerm_storage_container" "pso_files" {
+  name                  = var.storage_container_name
+  storage_account_name  = azurerm_storage_account.pso_sg.name
+  container_access_type = "private"
+}
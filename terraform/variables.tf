@@ -32,4 +32,10 @@ variable "password_development" {
   type        = string
   description = "The password for the local account that will be created on the new VM."
   default     = "SuperSecurePassword"
-}
\ No newline at end of file
+}
+
+variable "storage_container_name" {
+  type        = string
+  description = "Name of the blob container that stores uploaded files."
+  default     = "files"
+}